@@ -1,70 +1,298 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
 	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/go-chi/cors"
 	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
 	"github.com/oklog/oklog/pkg/group"
 
 	"phonebook/cmd/container"
 	httpService "phonebook/cmd/http"
 	"phonebook/config"
+	authrepo "phonebook/internal/auth/repository"
 	"phonebook/internal/global"
+	"phonebook/pkg/auth"
+	pkglogger "phonebook/pkg/logger"
 	"phonebook/pkg/queryable"
+	"phonebook/pkg/tenant"
+	"phonebook/pkg/validator"
 )
 
+// shutdownTimeout how long in flight requests are given to finish on shutdown
+const shutdownTimeout = 30 * time.Second
+
 func main() {
-	con := global.DB()
-	defer con.Close()
+	cfg, err := config.Init(os.Args[1:])
+	if err == flag.ErrHelp {
+		os.Exit(0)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	logger := global.InitLogger()
+	if err = logger.SetLevel(cfg.LogLevel); err != nil {
+		panic(err)
+	}
+	logger.Log(append([]interface{}{"config", "effective"}, cfg.Redacted()...)...)
+
+	con, err := global.OpenDB(context.Background(), cfg, logger)
+	if err != nil {
+		panic(err)
+	}
+	defer func() {
+		logger.Log("database", "close", "err", con.Close())
+	}()
 
 	queryable.MigrateAndSeed(con.DB)
 
-	cfg, err := config.Get()
+	// phone_default_region is checked by Config.Validate
+	validator.SetPhoneRegion(cfg.PhoneRegion)
 
+	authenticator, err := newAuthenticator(cfg, con)
 	if err != nil {
 		panic(err)
 	}
 
-	logger := global.InitLogger()
+	containerHTTP := container.CreateContainer("http", con, logger)
 
-	containerHTTP := container.CreateContainer("http", logger)
+	var origins atomic.Value
+	origins.Store(cfg.CORSOrigins)
+	subscribeReload(logger, con, &origins)
 
 	var g group.Group
 
-	initHTTP(cfg.HTTPAddress, containerHTTP, &g, logger)
+	initHTTP(cfg, containerHTTP, authenticator, &origins, &g, logger)
+	initExportWorker(container.CreateContainer("export_worker", con, logger), &g, logger)
+	initRetention(container.CreateContainer("retention", con, logger), &g, logger)
+	initReload(&g, logger)
+	initSignal(&g, logger)
 
 	logger.Log("exit", g.Run())
 }
 
 func initHTTP(
-	HTTPAddress string,
+	cfg *config.Config,
 	container *container.Container,
+	authenticator *auth.Authenticator,
+	origins *atomic.Value,
 	g *group.Group,
 	logger log.Logger,
 ) {
 	httpLogger := log.With(logger, "component", "http")
 	router := chi.NewRouter()
 	corsHandler := cors.New(cors.Options{
-		AllowedOrigins:   []string{"*"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "Client-ID", "Client-Secret"},
+		AllowOriginFunc:  allowOrigin(origins),
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "Client-ID", "Client-Secret", "If-Match", "If-None-Match", tenant.Header},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "PATCH", "OPTIONS"},
-		ExposedHeaders:   []string{"Link"},
+		ExposedHeaders:   []string{"Link", "ETag", "Content-Disposition"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	})
 	router.Use(corsHandler.Handler)
-	router.Handle("/healthy", httpService.HealthyCheck())
-	router.Mount("/v1", httpService.MakeHandler(container, httpLogger))
+	router.Handle("/healthy", httpService.HealthyCheck(container.DB))
+	router.Handle("/healthy/database", httpService.DatabaseStats(container.DB))
+	router.With(auth.Middleware(authenticator), tenant.Middleware).Mount("/v1", httpService.MakeHandler(container, httpLogger))
+	server := &http.Server{
+		Addr:         cfg.HTTPAddress,
+		Handler:      router,
+		ReadTimeout:  cfg.HTTPReadTimeout,
+		WriteTimeout: cfg.HTTPWriteTimeout,
+	}
 	g.Add(func() error {
-		logger.Log("transport", "debug/HTTP", "addr", HTTPAddress)
-		return http.ListenAndServe(HTTPAddress, router)
+		logger.Log("transport", "debug/HTTP", "addr", cfg.HTTPAddress)
+		err := server.ListenAndServe()
+		if err == http.ErrServerClosed {
+			return nil
+		}
+		return err
 	}, func(err error) {
-		if nil != err {
-			logger.Log("transport", "debug/HTTP", "addr", HTTPAddress, "error", err)
-			panic(err)
+		logger.Log("transport", "debug/HTTP", "addr", cfg.HTTPAddress, "shutdown", err)
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		server.Shutdown(ctx)
+	})
+}
+
+// initSignal stop every actor on SIGINT or SIGTERM so in flight requests finish and
+// the database pool is closed
+func initSignal(
+	g *group.Group,
+	logger log.Logger,
+) {
+	ctx, cancel := context.WithCancel(context.Background())
+	g.Add(func() error {
+		stop := make(chan os.Signal, 1)
+		signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+		defer signal.Stop(stop)
+
+		select {
+		case sig := <-stop:
+			return fmt.Errorf("received signal %s", sig)
+		case <-ctx.Done():
+			return nil
+		}
+	}, func(error) {
+		cancel()
+	})
+}
+
+// newAuthenticator accept HS256 tokens when JWT_SECRET is set, RS256 tokens when
+// JWT_PUBLIC_KEY_FILE is set and api keys stored in database
+func newAuthenticator(cfg *config.Config, con *sqlx.DB) (*auth.Authenticator, error) {
+	opts := auth.Options{
+		Issuer:   cfg.JWTIssuer,
+		Audience: cfg.JWTAudience,
+		APIKeys:  authrepo.NewPostgres(con),
+	}
+
+	if cfg.JWTSecret != "" {
+		opts.HMACSecret = []byte(cfg.JWTSecret)
+	}
+
+	if cfg.JWTPublicKeyFile != "" {
+		key, err := auth.LoadRSAPublicKey(cfg.JWTPublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		opts.RSAPublicKey = key
+	}
+
+	return auth.NewAuthenticator(opts), nil
+}
+
+func initExportWorker(
+	container *container.Container,
+	g *group.Group,
+	logger log.Logger,
+) {
+	ctx, cancel := context.WithCancel(context.Background())
+	g.Add(func() error {
+		logger.Log("worker", "export")
+		return container.Export.Work(ctx)
+	}, func(error) {
+		cancel()
+	})
+}
+
+func initRetention(
+	container *container.Container,
+	g *group.Group,
+	logger log.Logger,
+) {
+	ctx, cancel := context.WithCancel(context.Background())
+	g.Add(func() error {
+		logger.Log("worker", "retention")
+		return container.PhoneBook.RunRetention(ctx)
+	}, func(error) {
+		cancel()
+	})
+}
+
+// allowOrigin match request origin against the origins held by origins, which a
+// reload may replace while the server is running. "*" allow every origin
+func allowOrigin(origins *atomic.Value) func(r *http.Request, origin string) bool {
+	return func(r *http.Request, origin string) bool {
+		for _, allowed := range origins.Load().([]string) {
+			if allowed == "*" || strings.EqualFold(allowed, origin) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// subscribeReload apply reloaded settings that live outside config.Get, the log
+// level, the pool limits and the CORS origins
+func subscribeReload(logger pkglogger.KitxLogger, con *sqlx.DB, origins *atomic.Value) {
+	config.Subscribe(func(old, cfg *config.Config) {
+		if err := logger.SetLevel(cfg.LogLevel); err != nil {
+			logger.Log("config", "reload", "key", "log_level", "err", err)
+		}
+
+		con.SetMaxOpenConns(cfg.MaxOpenCon)
+		con.SetMaxIdleConns(cfg.MaxIdleCon)
+		con.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+
+		origins.Store(cfg.CORSOrigins)
+	})
+}
+
+// initReload reload configuration on SIGHUP and, every WatchInterval, when the
+// config file was modified. A rejected configuration leave the current one in place
+func initReload(
+	g *group.Group,
+	logger log.Logger,
+) {
+	ctx, cancel := context.WithCancel(context.Background())
+	g.Add(func() error {
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		defer signal.Stop(hup)
+
+		logger.Log("worker", "config_reload", "file", config.Path())
+		modified := modTime(config.Path())
+		for {
+			var watch <-chan time.Time
+			if cfg, err := config.Get(); err == nil && cfg.WatchInterval > 0 && config.Path() != "" {
+				watch = time.After(cfg.WatchInterval)
+			}
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-hup:
+				reload(logger, "signal")
+			case <-watch:
+				if modTime(config.Path()).Equal(modified) {
+					continue
+				}
+				reload(logger, "file")
+			}
+			modified = modTime(config.Path())
 		}
+	}, func(error) {
+		cancel()
 	})
 }
+
+func reload(logger log.Logger, trigger string) {
+	rejected, err := config.Reload()
+	if err != nil {
+		logger.Log("config", "reload", "trigger", trigger, "err", err)
+		return
+	}
+
+	for _, key := range rejected {
+		level.Warn(logger).Log("config", "reload", "trigger", trigger, "key", key, "msg", "change requires a restart, ignored")
+	}
+	logger.Log("config", "reloaded", "trigger", trigger)
+}
+
+// modTime last modification of file, zero when it cannot be read
+func modTime(path string) time.Time {
+	if path == "" {
+		return time.Time{}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
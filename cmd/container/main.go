@@ -2,20 +2,36 @@ package container
 
 import (
 	// internal package
+	authrepo "phonebook/internal/auth/repository"
+	"phonebook/internal/export"
+	exportrepo "phonebook/internal/export/repository"
+	"phonebook/internal/group"
+	grouprepo "phonebook/internal/group/repository"
 	"phonebook/internal/phonebook"
 	repo "phonebook/internal/phonebook/repository"
+	"phonebook/pkg/middleware"
 
 	// thirdparty package
 	"github.com/go-kit/kit/log"
+	"github.com/jmoiron/sqlx"
 )
 
 type Container struct {
+	DB        *sqlx.DB
 	PhoneBook *phonebook.Service
+	Export    *export.Service
+	Group     *group.Service
+	Access    middleware.Authorizer
 }
 
-func CreateContainer(actor string, logger log.Logger) *Container {
-	svc := phonebook.NewService(repo.NewPostgres(), actor, logger)
+// CreateContainer wire services of actor on db, the pool is shared by every container
+func CreateContainer(actor string, db *sqlx.DB, logger log.Logger) *Container {
+	svc := phonebook.NewService(db, repo.NewPostgres(db), actor, logger)
 	return &Container{
+		DB:        db,
 		PhoneBook: svc,
+		Export:    export.NewService(db, exportrepo.NewPostgres(db), svc, actor, logger),
+		Group:     group.NewService(db, grouprepo.NewPostgres(db), actor, logger),
+		Access:    authrepo.NewPostgres(db),
 	}
 }
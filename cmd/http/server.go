@@ -1,16 +1,22 @@
 package http
 
 import (
+	"encoding/json"
 	"net/http"
 	"phonebook/cmd/container"
-	"phonebook/internal/global"
+	rbac "phonebook/internal/auth"
 
+	exporthttp "phonebook/internal/export/transport/http"
+	grouphttp "phonebook/internal/group/transport/http"
 	phonebookhttp "phonebook/internal/phonebook/transport/http"
 	kitxserver "phonebook/pkg/httperror"
+	"phonebook/pkg/middleware"
 
 	"github.com/go-chi/chi"
+	"github.com/go-kit/kit/endpoint"
 	kitlog "github.com/go-kit/kit/log"
 	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/jmoiron/sqlx"
 )
 
 func MakeHandler(container *container.Container, logger kitlog.Logger) http.Handler {
@@ -22,13 +28,16 @@ func MakeHandler(container *container.Container, logger kitlog.Logger) http.Hand
 	}
 
 	registerPhoneBookHandler(router, container, logger, opts)
+	registerExportHandler(router, container, logger, opts)
+	registerGroupHandler(router, container, logger, opts)
+	registerAdminHandler(router, container, logger, opts)
 
 	return router
 }
 
-func HealthyCheck() http.HandlerFunc {
+func HealthyCheck(db *sqlx.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		_, err := global.DB().Exec("SELECT 1")
+		err := db.PingContext(r.Context())
 		if nil != err {
 			w.WriteHeader(http.StatusInternalServerError)
 			return
@@ -38,21 +47,148 @@ func HealthyCheck() http.HandlerFunc {
 	}
 }
 
+// DatabaseStats report statistics of the connection pool as json
+func DatabaseStats(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(db.Stats())
+	}
+}
+
+// authorize build endpoint middleware requiring permission declared by route
+func authorize(container *container.Container) func(permission string) endpoint.Middleware {
+	return func(permission string) endpoint.Middleware {
+		return middleware.Authorize(container.Access, permission)
+	}
+}
+
 func registerPhoneBookHandler(r *chi.Mux, container *container.Container, logger kitlog.Logger, opts []kithttp.ServerOption) {
+	require := authorize(container)
 	r.Get("/phonebook", phonebookhttp.Get(
 		container.PhoneBook,
 		logger,
-		append(opts)).ServeHTTP)
+		opts,
+		require(rbac.PermissionRead)).ServeHTTP)
+	r.Get("/phonebook/search", phonebookhttp.Search(
+		container.PhoneBook,
+		logger,
+		opts,
+		require(rbac.PermissionRead)).ServeHTTP)
+	r.Get("/phonebook/export.vcf", phonebookhttp.Export(
+		container.PhoneBook,
+		logger,
+		opts,
+		require(rbac.PermissionRead)).ServeHTTP)
+	r.Get("/phonebook/{id}.vcf", phonebookhttp.FetchVCard(
+		container.PhoneBook,
+		logger,
+		opts,
+		require(rbac.PermissionRead)).ServeHTTP)
+	r.Get("/phonebook/{id}/history", phonebookhttp.History(
+		container.PhoneBook,
+		logger,
+		opts,
+		require(rbac.PermissionRead)).ServeHTTP)
+	r.Get("/phonebook/{id}", phonebookhttp.FetchByID(
+		container.PhoneBook,
+		logger,
+		opts,
+		require(rbac.PermissionRead)).ServeHTTP)
 	r.Post("/phonebook", phonebookhttp.Create(
 		container.PhoneBook,
 		logger,
-		append(opts)).ServeHTTP)
+		opts,
+		require(rbac.PermissionWrite)).ServeHTTP)
+	r.Post("/phonebook/import", phonebookhttp.Import(
+		container.PhoneBook,
+		logger,
+		opts,
+		require(rbac.PermissionWrite)).ServeHTTP)
+	r.Post("/phonebook/{id}/restore", phonebookhttp.Restore(
+		container.PhoneBook,
+		logger,
+		opts,
+		require(rbac.PermissionRestore)).ServeHTTP)
 	r.Put("/phonebook/{id}", phonebookhttp.Update(
 		container.PhoneBook,
 		logger,
-		append(opts)).ServeHTTP)
+		opts,
+		require(rbac.PermissionWrite)).ServeHTTP)
+	r.Patch("/phonebook/{id}", phonebookhttp.Patch(
+		container.PhoneBook,
+		logger,
+		opts,
+		require(rbac.PermissionWrite)).ServeHTTP)
 	r.Delete("/phonebook/{id}", phonebookhttp.Remove(
 		container.PhoneBook,
 		logger,
-		append(opts)).ServeHTTP)
+		opts,
+		require(rbac.PermissionDelete)).ServeHTTP)
+}
+
+func registerExportHandler(r *chi.Mux, container *container.Container, logger kitlog.Logger, opts []kithttp.ServerOption) {
+	require := authorize(container)
+	r.Post("/exports", exporthttp.Create(
+		container.Export,
+		logger,
+		opts,
+		require(rbac.PermissionRead)).ServeHTTP)
+	r.Get("/exports/{id}", exporthttp.FetchByID(
+		container.Export,
+		logger,
+		opts,
+		require(rbac.PermissionRead)).ServeHTTP)
+}
+
+func registerGroupHandler(r *chi.Mux, container *container.Container, logger kitlog.Logger, opts []kithttp.ServerOption) {
+	require := authorize(container)
+	r.Get("/groups", grouphttp.List(
+		container.Group,
+		logger,
+		opts,
+		require(rbac.PermissionRead)).ServeHTTP)
+	r.Post("/groups", grouphttp.Create(
+		container.Group,
+		logger,
+		opts,
+		require(rbac.PermissionWrite)).ServeHTTP)
+	r.Get("/groups/{id}", grouphttp.FetchByID(
+		container.Group,
+		logger,
+		opts,
+		require(rbac.PermissionRead)).ServeHTTP)
+	r.Put("/groups/{id}", grouphttp.Update(
+		container.Group,
+		logger,
+		opts,
+		require(rbac.PermissionWrite)).ServeHTTP)
+	r.Delete("/groups/{id}", grouphttp.Remove(
+		container.Group,
+		logger,
+		opts,
+		require(rbac.PermissionWrite)).ServeHTTP)
+	r.Post("/groups/{id}/members", grouphttp.AddMembers(
+		container.Group,
+		logger,
+		opts,
+		require(rbac.PermissionWrite)).ServeHTTP)
+	r.Delete("/groups/{id}/members", grouphttp.RemoveMembers(
+		container.Group,
+		logger,
+		opts,
+		require(rbac.PermissionWrite)).ServeHTTP)
+}
+
+func registerAdminHandler(r *chi.Mux, container *container.Container, logger kitlog.Logger, opts []kithttp.ServerOption) {
+	require := authorize(container)
+	r.Get("/admin/phonebook/deleted", phonebookhttp.ListDeleted(
+		container.PhoneBook,
+		logger,
+		opts,
+		require(rbac.PermissionRestore)).ServeHTTP)
+	r.Delete("/admin/phonebook/{id}", phonebookhttp.Purge(
+		container.PhoneBook,
+		logger,
+		opts,
+		require(rbac.PermissionPurge)).ServeHTTP)
 }
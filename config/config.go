@@ -1,48 +1,131 @@
 package config
 
-import "os"
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
 
-var config *Config
+var (
+	// config hold the current *Config, it is swapped as a whole by Reload
+	config atomic.Value
+	lock   sync.Mutex
+	args   []string
+	path   string
+)
 
 const (
 	SERVICENAME = "phonebook"
-	ENVIRONMENT = "ENVIRONMENT"
-	HTTPPORT    = "HTTP_PORT"
-	HTTPHOST    = "HTTP_HOST"
+	CONFIGFILE  = "CONFIG_FILE"
 )
 
-// Config phonebook application configuration
+// Config phonebook application configuration. Every field is read, in increasing precedence,
+// from defaults, the config file (key), the environment (env) and the command line (key with
+// dashes). Secret fields are redacted when the config is dumped and only hot fields are
+// changed by Reload, the others need a restart
 type Config struct {
-	HTTPAddress        string
-	Enviroment         string
-	DBType             string
-	DBConnectionString string
-	MaxOpenCon         string
-	MaxIdleCon         string
+	HTTPAddress        string        `key:"http_address" env:"HTTP_ADDRESS"`
+	HTTPReadTimeout    time.Duration `key:"http_read_timeout" env:"HTTP_READ_TIMEOUT"`
+	HTTPWriteTimeout   time.Duration `key:"http_write_timeout" env:"HTTP_WRITE_TIMEOUT"`
+	CORSOrigins        []string      `key:"cors_allowed_origins" env:"CORS_ALLOWED_ORIGINS" reload:"hot"`
+	LogLevel           string        `key:"log_level" env:"LOG_LEVEL" reload:"hot"`
+	WatchInterval      time.Duration `key:"config_watch_interval" env:"CONFIG_WATCH_INTERVAL" reload:"hot"`
+	Enviroment         string        `key:"environment" env:"ENVIRONMENT"`
+	DBType             string        `key:"db_type" env:"DB_TYPE"`
+	DBConnectionString string        `key:"db_connection_string" env:"DB_CONNECTION_STRING" secret:"dsn"`
+	MaxOpenCon         int           `key:"db_max_open_conns" env:"DB_MAX_OPEN_CONNS" reload:"hot"`
+	MaxIdleCon         int           `key:"db_max_idle_conns" env:"DB_MAX_IDLE_CONNS" reload:"hot"`
+	ConnMaxLifetime    time.Duration `key:"db_conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME" reload:"hot"`
+	DBConnectAttempts  int           `key:"db_connect_attempts" env:"DB_CONNECT_ATTEMPTS"`
+	DBConnectBackoff   time.Duration `key:"db_connect_backoff" env:"DB_CONNECT_BACKOFF"`
+	CursorSecret       string        `key:"cursor_secret" env:"CURSOR_SECRET" secret:"true"`
+	DefaultPageSize    int           `key:"default_page_size" env:"DEFAULT_PAGE_SIZE" reload:"hot"`
+	MaxPageSize        int           `key:"max_page_size" env:"MAX_PAGE_SIZE" reload:"hot"`
+	PhoneRegion        string        `key:"phone_default_region" env:"PHONE_DEFAULT_REGION"`
+	ImportBatchSize    int           `key:"import_batch_size" env:"IMPORT_BATCH_SIZE" reload:"hot"`
+	ExportDir          string        `key:"export_dir" env:"EXPORT_DIR"`
+	ExportChunkSize    int           `key:"export_chunk_size" env:"EXPORT_CHUNK_SIZE" reload:"hot"`
+	ExportSyncMax      int           `key:"export_sync_max" env:"EXPORT_SYNC_MAX" reload:"hot"`
+	ExportPollInterval time.Duration `key:"export_poll_interval" env:"EXPORT_POLL_INTERVAL" reload:"hot"`
+	ExportStaleAfter   time.Duration `key:"export_stale_after" env:"EXPORT_STALE_AFTER" reload:"hot"`
+	RetentionDays      int           `key:"retention_days" env:"RETENTION_DAYS" reload:"hot"`
+	RetentionInterval  time.Duration `key:"retention_interval" env:"RETENTION_INTERVAL"`
+	RetentionBatchSize int           `key:"retention_batch_size" env:"RETENTION_BATCH_SIZE" reload:"hot"`
+	JWTSecret          string        `key:"jwt_secret" env:"JWT_SECRET" secret:"true"`
+	JWTPublicKeyFile   string        `key:"jwt_public_key_file" env:"JWT_PUBLIC_KEY_FILE"`
+	JWTIssuer          string        `key:"jwt_issuer" env:"JWT_ISSUER"`
+	JWTAudience        string        `key:"jwt_audience" env:"JWT_AUDIENCE"`
 }
 
-var defaultConfig = &Config{
-	HTTPAddress:        getEnvOrDefault(HTTPHOST, "") + ":" + getEnvOrDefault(HTTPPORT, "9080"),
-	Enviroment:         "development",
-	DBType:             "postgres",
-	DBConnectionString: "host=localhost port=5432 database=ledger user=root password=root sslmode=disable",
-	MaxOpenCon:         "15",
-	MaxIdleCon:         "10",
+// Default configuration used for every field that is not set
+func Default() *Config {
+	return &Config{
+		HTTPAddress:        ":9080",
+		HTTPReadTimeout:    30 * time.Second,
+		HTTPWriteTimeout:   5 * time.Minute,
+		CORSOrigins:        []string{"*"},
+		LogLevel:           "info",
+		WatchInterval:      10 * time.Second,
+		Enviroment:         "development",
+		DBType:             "postgres",
+		DBConnectionString: "host=localhost port=5432 database=ledger user=root password=root sslmode=disable",
+		MaxOpenCon:         15,
+		MaxIdleCon:         10,
+		ConnMaxLifetime:    30 * time.Minute,
+		DBConnectAttempts:  10,
+		DBConnectBackoff:   500 * time.Millisecond,
+		DefaultPageSize:    20,
+		MaxPageSize:        100,
+		PhoneRegion:        "ID",
+		ImportBatchSize:    500,
+		ExportDir:          filepath.Join(os.TempDir(), SERVICENAME+"-exports"),
+		ExportChunkSize:    500,
+		ExportSyncMax:      5000,
+		ExportPollInterval: 2 * time.Second,
+		ExportStaleAfter:   time.Minute,
+		RetentionDays:      0,
+		RetentionInterval:  24 * time.Hour,
+		RetentionBatchSize: 500,
+	}
 }
 
-func getEnvOrDefault(env string, defaultVal string) string {
-	e := os.Getenv(env)
-	if e == "" {
-		return defaultVal
+// Init load configuration from file, environment and command line args and make it
+// the one returned by Get, Reload read the same args again
+func Init(cmdArgs []string) (*Config, error) {
+	cfg, file, err := load(cmdArgs)
+	if nil != err {
+		return nil, err
 	}
-	return e
+
+	lock.Lock()
+	args, path = cmdArgs, file
+	config.Store(cfg)
+	lock.Unlock()
+	return cfg, nil
 }
 
+// Get current configuration, without Init it is loaded once from file and environment.
+// The returned value is never modified, a reload replaces it
 func Get() (*Config, error) {
-	// return defaultConfig
-	if config != nil {
-		return config, nil
+	if cfg, ok := config.Load().(*Config); ok {
+		return cfg, nil
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	if cfg, ok := config.Load().(*Config); ok {
+		return cfg, nil
+	}
+
+	cfg, file, err := load(nil)
+	if nil != err {
+		return nil, err
 	}
 
-	return defaultConfig, nil
+	path = file
+	config.Store(cfg)
+	return cfg, nil
 }
@@ -0,0 +1,245 @@
+package config
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// field one setting of Config with the names it is read by
+type field struct {
+	name   string
+	key    string
+	env    string
+	secret string
+	hot    bool
+	value  reflect.Value
+}
+
+func fieldsOf(cfg *Config) []field {
+	val := reflect.ValueOf(cfg).Elem()
+	typ := val.Type()
+
+	fields := make([]field, 0, typ.NumField())
+	for i := 0; i < typ.NumField(); i++ {
+		tag := typ.Field(i).Tag
+		fields = append(fields, field{
+			name:   typ.Field(i).Name,
+			key:    tag.Get("key"),
+			env:    tag.Get("env"),
+			secret: tag.Get("secret"),
+			hot:    tag.Get("reload") == "hot",
+			value:  val.Field(i),
+		})
+	}
+	return fields
+}
+
+// flagName command line name of field, -db-max-open-conns for db_max_open_conns
+func (f field) flagName() string {
+	return strings.Replace(f.key, "_", "-", -1)
+}
+
+// flagValue keep raw command line value, it is applied after the file and the environment
+type flagValue struct {
+	key    string
+	values map[string]string
+}
+
+func (v *flagValue) String() string {
+	return ""
+}
+
+func (v *flagValue) Set(raw string) error {
+	v.values[v.key] = raw
+	return nil
+}
+
+// Load build configuration from defaults, config file, environment and command line args,
+// each overriding the previous one, and validate it. The file is named by -config or
+// CONFIG_FILE, a .json file is read as json and any other as yaml
+func Load(args []string) (*Config, error) {
+	cfg, _, err := load(args)
+	return cfg, err
+}
+
+// load same as Load and also return the path of the config file read, if any
+func load(args []string) (*Config, string, error) {
+	cfg := Default()
+	fields := fieldsOf(cfg)
+
+	flags := flag.NewFlagSet(SERVICENAME, flag.ContinueOnError)
+	path := flags.String("config", os.Getenv(CONFIGFILE), "path of yaml or json config file")
+	values := make(map[string]string)
+	for _, f := range fields {
+		flags.Var(&flagValue{key: f.key, values: values}, f.flagName(), "overrides "+f.key+" and "+f.env)
+	}
+
+	err := flags.Parse(args)
+	if nil != err {
+		return nil, "", err
+	}
+
+	if *path != "" {
+		err = loadFile(fields, *path)
+		if nil != err {
+			return nil, "", err
+		}
+	}
+
+	err = loadEnv(cfg, fields)
+	if nil != err {
+		return nil, "", err
+	}
+
+	for _, f := range fields {
+		raw, ok := values[f.key]
+		if !ok {
+			continue
+		}
+
+		if err = setValue(f.value, raw); nil != err {
+			return nil, "", fmt.Errorf("flag -%s: %v", f.flagName(), err)
+		}
+	}
+
+	err = cfg.Validate()
+	if nil != err {
+		return nil, "", err
+	}
+
+	return cfg, *path, nil
+}
+
+func loadFile(fields []field, path string) error {
+	raw, err := ioutil.ReadFile(path)
+	if nil != err {
+		return fmt.Errorf("config file: %v", err)
+	}
+
+	document := make(map[string]interface{})
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(raw, &document)
+	} else {
+		err = yaml.Unmarshal(raw, &document)
+	}
+	if nil != err {
+		return fmt.Errorf("config file %s: %v", path, err)
+	}
+
+	byKey := make(map[string]field, len(fields))
+	for _, f := range fields {
+		byKey[f.key] = f
+	}
+
+	for key, value := range document {
+		f, ok := byKey[key]
+		if !ok {
+			return fmt.Errorf("config file %s: unknown key %q", path, key)
+		}
+
+		if nil == value {
+			continue
+		}
+
+		if err = setValue(f.value, scalar(value)); nil != err {
+			return fmt.Errorf("config file %s: %s: %v", path, key, err)
+		}
+	}
+
+	return nil
+}
+
+func loadEnv(cfg *Config, fields []field) error {
+	// HTTP_HOST and HTTP_PORT are kept for deployments predating HTTP_ADDRESS
+	host, port := os.Getenv("HTTP_HOST"), os.Getenv("HTTP_PORT")
+	if host != "" || port != "" {
+		if port == "" {
+			port = "9080"
+		}
+		cfg.HTTPAddress = host + ":" + port
+	}
+
+	for _, f := range fields {
+		raw, ok := os.LookupEnv(f.env)
+		if !ok {
+			continue
+		}
+
+		if err := setValue(f.value, raw); nil != err {
+			return fmt.Errorf("env %s: %v", f.env, err)
+		}
+	}
+
+	return nil
+}
+
+// scalar turn value decoded from the config file into the text accepted by setValue
+func scalar(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, scalar(item))
+		}
+		return strings.Join(items, ",")
+	}
+	return fmt.Sprint(value)
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func setValue(field reflect.Value, raw string) error {
+	raw = strings.TrimSpace(raw)
+
+	if field.Type() == durationType {
+		v, err := time.ParseDuration(raw)
+		if nil != err {
+			return err
+		}
+		field.SetInt(int64(v))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Int:
+		v, err := strconv.Atoi(raw)
+		if nil != err {
+			return fmt.Errorf("%q is not an integer", raw)
+		}
+		field.SetInt(int64(v))
+	case reflect.Bool:
+		v, err := strconv.ParseBool(raw)
+		if nil != err {
+			return fmt.Errorf("%q is not a boolean", raw)
+		}
+		field.SetBool(v)
+	case reflect.Slice:
+		items := make([]string, 0)
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+
+	return nil
+}
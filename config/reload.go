@@ -0,0 +1,66 @@
+package config
+
+import (
+	"reflect"
+)
+
+// Subscriber told about a reload with the previous and the new configuration
+type Subscriber func(old, new *Config)
+
+var subscribers []Subscriber
+
+// Subscribe call fn after every successful Reload
+func Subscribe(fn Subscriber) {
+	lock.Lock()
+	subscribers = append(subscribers, fn)
+	lock.Unlock()
+}
+
+// Path of the config file read by Init, empty when configuration only comes from
+// environment and command line
+func Path() string {
+	lock.Lock()
+	defer lock.Unlock()
+	return path
+}
+
+// Reload read configuration again from the sources given to Init, validate it and
+// swap it in, then notify subscribers. An invalid configuration is rejected as a whole
+// and the current one is kept. Settings that need a restart keep their current value,
+// their keys are returned so the caller can warn about them
+func Reload() (rejected []string, err error) {
+	lock.Lock()
+	old, ok := config.Load().(*Config)
+	if !ok {
+		lock.Unlock()
+		_, err = Get()
+		return nil, err
+	}
+
+	cfg, file, err := load(args)
+	if nil != err {
+		lock.Unlock()
+		return nil, err
+	}
+
+	current := fieldsOf(old)
+	for i, f := range fieldsOf(cfg) {
+		if f.hot || reflect.DeepEqual(f.value.Interface(), current[i].value.Interface()) {
+			continue
+		}
+
+		f.value.Set(current[i].value)
+		rejected = append(rejected, f.key)
+	}
+
+	path = file
+	config.Store(cfg)
+	notify := append([]Subscriber(nil), subscribers...)
+	lock.Unlock()
+
+	for _, fn := range notify {
+		fn(old, cfg)
+	}
+
+	return rejected, nil
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+
+	"phonebook/pkg/validator"
+)
+
+// minCursorSecret length of cursor_secret, 32 bytes match the output of the HMAC
+const minCursorSecret = 32
+
+var logLevels = map[string]bool{"debug": true, "info": true, "warn": true, "error": true}
+
+// ValidationError every problem found in configuration
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration: " + strings.Join(e.Problems, "; ")
+}
+
+// Validate check every setting and report all problems at once
+func (c *Config) Validate() error {
+	problems := make([]string, 0)
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+
+	_, _, err := net.SplitHostPort(c.HTTPAddress)
+	check(nil == err, "http_address %q must be host:port", c.HTTPAddress)
+	check(c.HTTPReadTimeout >= 0, "http_read_timeout must not be negative")
+	check(c.HTTPWriteTimeout >= 0, "http_write_timeout must not be negative")
+	check(c.Enviroment != "", "environment is required")
+	check(len(c.CORSOrigins) > 0, "cors_allowed_origins must not be empty")
+	_, ok := logLevels[c.LogLevel]
+	check(ok, "log_level %q must be one of debug, info, warn, error", c.LogLevel)
+	check(c.WatchInterval >= 0, "config_watch_interval must not be negative")
+
+	check(c.DBType == "postgres", "db_type %q is not supported, only postgres", c.DBType)
+	check(c.DBConnectionString != "", "db_connection_string is required")
+	check(c.MaxOpenCon > 0, "db_max_open_conns must be positive")
+	check(c.MaxIdleCon >= 0 && c.MaxIdleCon <= c.MaxOpenCon, "db_max_idle_conns must be between 0 and db_max_open_conns")
+	check(c.ConnMaxLifetime >= 0, "db_conn_max_lifetime must not be negative")
+	check(c.DBConnectAttempts > 0, "db_connect_attempts must be positive")
+	check(c.DBConnectBackoff > 0, "db_connect_backoff must be positive")
+
+	// cursors are signed with it, it has no default so a known key is never used
+	check(c.CursorSecret != "", "cursor_secret is required")
+	check(c.CursorSecret == "" || len(c.CursorSecret) >= minCursorSecret, "cursor_secret must be at least %d characters", minCursorSecret)
+	check(c.DefaultPageSize > 0, "default_page_size must be positive")
+	check(c.DefaultPageSize <= c.MaxPageSize, "default_page_size must not exceed max_page_size")
+	check(validator.IsPhoneRegion(c.PhoneRegion), "phone_default_region %q is not supported", c.PhoneRegion)
+
+	check(c.ImportBatchSize > 0, "import_batch_size must be positive")
+	check(c.ExportDir != "", "export_dir is required")
+	check(c.ExportChunkSize > 0, "export_chunk_size must be positive")
+	check(c.ExportSyncMax > 0, "export_sync_max must be positive")
+	check(c.ExportPollInterval > 0, "export_poll_interval must be positive")
+	check(c.ExportStaleAfter > 0, "export_stale_after must be positive")
+	check(c.RetentionDays >= 0, "retention_days must not be negative")
+	check(c.RetentionInterval > 0, "retention_interval must be positive")
+	check(c.RetentionBatchSize > 0, "retention_batch_size must be positive")
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+const redacted = "******"
+
+var dsnPassword = regexp.MustCompile(`(password=)('[^']*'|\S+)|(://[^:/@]+:)[^@]+(@)`)
+
+// Redacted effective configuration as key value pairs for logging, secrets are
+// masked and only the password of the connection string is
+func (c *Config) Redacted() []interface{} {
+	fields := fieldsOf(c)
+	keyvals := make([]interface{}, 0, 2*len(fields))
+	for _, f := range fields {
+		value := fmt.Sprint(f.value.Interface())
+		if items, ok := f.value.Interface().([]string); ok {
+			value = strings.Join(items, ",")
+		}
+		switch {
+		case f.secret == "dsn":
+			value = dsnPassword.ReplaceAllString(value, "${1}${3}"+redacted+"${4}")
+		case f.secret != "" && value != "":
+			value = redacted
+		}
+		keyvals = append(keyvals, f.key, value)
+	}
+	return keyvals
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	secret := strings.Repeat("s", minCursorSecret)
+
+	tests := []struct {
+		name    string
+		change  func(c *Config)
+		problem string
+	}{
+		{
+			name:   "default with a secret",
+			change: func(c *Config) { c.CursorSecret = secret },
+		},
+		{
+			name:    "cursor secret missing",
+			change:  func(c *Config) {},
+			problem: "cursor_secret is required",
+		},
+		{
+			name: "cursor secret missing outside production",
+			change: func(c *Config) {
+				c.Enviroment = "staging"
+			},
+			problem: "cursor_secret is required",
+		},
+		{
+			name: "phone region lower case",
+			change: func(c *Config) {
+				c.CursorSecret = secret
+				c.PhoneRegion = "sg"
+			},
+		},
+		{
+			name:    "phone region missing",
+			change:  func(c *Config) { c.PhoneRegion = "" },
+			problem: `phone_default_region "" is not supported`,
+		},
+		{
+			name:    "phone region not supported",
+			change:  func(c *Config) { c.PhoneRegion = "XX" },
+			problem: `phone_default_region "XX" is not supported`,
+		},
+		{
+			name:    "cursor secret too short",
+			change:  func(c *Config) { c.CursorSecret = SERVICENAME },
+			problem: "cursor_secret must be at least",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := Default()
+			test.change(c)
+			err := c.Validate()
+
+			if test.problem == "" {
+				if nil != err {
+					t.Fatalf("got error %v, want none", err)
+				}
+				return
+			}
+
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("got error %v, want ValidationError", err)
+			}
+			for _, problem := range verr.Problems {
+				if strings.HasPrefix(problem, test.problem) {
+					return
+				}
+			}
+			t.Errorf("got problems %q, want one starting with %q", verr.Problems, test.problem)
+		})
+	}
+}
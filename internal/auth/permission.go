@@ -0,0 +1,10 @@
+package auth
+
+// permissions checked by routes, granted to roles in role_permission
+const (
+	PermissionRead    = "phonebook:read"
+	PermissionWrite   = "phonebook:write"
+	PermissionDelete  = "phonebook:delete"
+	PermissionRestore = "phonebook:restore"
+	PermissionPurge   = "phonebook:purge"
+)
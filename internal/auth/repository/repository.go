@@ -0,0 +1,55 @@
+package repository
+
+import (
+	// internal golang package
+	"context"
+	"database/sql"
+
+	// internal package
+	"phonebook/internal/global"
+	"phonebook/pkg/auth"
+	"phonebook/pkg/queryable"
+	"phonebook/pkg/tenant"
+
+	// thirdparty package
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository struct {
+	db *sqlx.DB
+}
+
+func NewPostgres(db *sqlx.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// FindAPIKey , get api key by client id, nil when it does not exist or is revoked.
+// The tenant is not known yet so keys of every tenant are looked up
+func (r *Repository) FindAPIKey(ctx context.Context, clientID string) (result *auth.APIKey, err error) {
+	err = queryable.RunInTransaction(tenant.System(ctx), r.db, func(ctx context.Context) error {
+		result = &auth.APIKey{}
+		return global.GetQuery(ctx, r.db).GetContext(ctx, result, `SELECT tenant_id, client_id, name, secret_hash FROM api_key
+		WHERE client_id = $1 AND revoked_date_utc IS NULL`, clientID)
+	})
+	if sql.ErrNoRows == err {
+		return nil, nil
+	}
+
+	if nil != err {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// HasPermission , report whether any role assigned to subject in tenant of ctx grants permission
+func (r *Repository) HasPermission(ctx context.Context, subject string, permission string) (granted bool, err error) {
+	err = queryable.RunInTransaction(ctx, r.db, func(ctx context.Context) error {
+		return global.GetQuery(ctx, r.db).GetContext(ctx, &granted, `SELECT EXISTS (
+			SELECT 1 FROM role_assignment ra
+			JOIN role_permission rp ON rp.role = ra.role
+			WHERE ra.subject = $1 AND rp.permission = $2
+		)`, subject, permission)
+	})
+	return granted, err
+}
@@ -0,0 +1,54 @@
+CREATE TABLE IF NOT EXISTS "role" (
+    "name" VARCHAR(64) NOT NULL,
+    "description" VARCHAR(255),
+    CONSTRAINT "pk_role" PRIMARY KEY("name")
+);
+
+CREATE TABLE IF NOT EXISTS "permission" (
+    "name" VARCHAR(64) NOT NULL,
+    "description" VARCHAR(255),
+    CONSTRAINT "pk_permission" PRIMARY KEY("name")
+);
+
+CREATE TABLE IF NOT EXISTS "role_permission" (
+    "role" VARCHAR(64) NOT NULL,
+    "permission" VARCHAR(64) NOT NULL,
+    CONSTRAINT "pk_role_permission" PRIMARY KEY("role", "permission"),
+    CONSTRAINT "fk_role_permission_role" FOREIGN KEY("role") REFERENCES "role"("name") ON DELETE CASCADE,
+    CONSTRAINT "fk_role_permission_permission" FOREIGN KEY("permission") REFERENCES "permission"("name") ON DELETE CASCADE
+);
+
+-- subject is the id of the principal, the jwt subject or the api key client id
+CREATE TABLE IF NOT EXISTS "role_assignment" (
+    "subject" VARCHAR(255) NOT NULL,
+    "role" VARCHAR(64) NOT NULL,
+    "created_date_utc" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "created_by" VARCHAR(255),
+    CONSTRAINT "pk_role_assignment" PRIMARY KEY("subject", "role"),
+    CONSTRAINT "fk_role_assignment_role" FOREIGN KEY("role") REFERENCES "role"("name") ON DELETE CASCADE
+);
+
+INSERT INTO "role" ("name", "description") VALUES
+    ('reader', 'list, search and read profiles'),
+    ('editor', 'reader who may also create and update profiles'),
+    ('admin', 'editor who may also delete, restore and purge profiles')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO "permission" ("name", "description") VALUES
+    ('phonebook:read', 'list, search, read and export profiles'),
+    ('phonebook:write', 'create, update and import profiles'),
+    ('phonebook:delete', 'delete profiles'),
+    ('phonebook:restore', 'list and restore deleted profiles'),
+    ('phonebook:purge', 'permanently remove deleted profiles')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO "role_permission" ("role", "permission") VALUES
+    ('reader', 'phonebook:read'),
+    ('editor', 'phonebook:read'),
+    ('editor', 'phonebook:write'),
+    ('admin', 'phonebook:read'),
+    ('admin', 'phonebook:write'),
+    ('admin', 'phonebook:delete'),
+    ('admin', 'phonebook:restore'),
+    ('admin', 'phonebook:purge')
+ON CONFLICT DO NOTHING;
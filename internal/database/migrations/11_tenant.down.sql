@@ -0,0 +1,42 @@
+DROP POLICY IF EXISTS "tenant_isolation" ON "role_assignment";
+ALTER TABLE "role_assignment" NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE "role_assignment" DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS "tenant_isolation" ON "api_key";
+ALTER TABLE "api_key" NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE "api_key" DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS "tenant_isolation" ON "export_job";
+ALTER TABLE "export_job" NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE "export_job" DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS "tenant_isolation" ON "phone_book_audit";
+ALTER TABLE "phone_book_audit" NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE "phone_book_audit" DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS "tenant_isolation" ON "phone_book_address";
+ALTER TABLE "phone_book_address" NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE "phone_book_address" DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS "tenant_isolation" ON "phone_book_email";
+ALTER TABLE "phone_book_email" NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE "phone_book_email" DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS "tenant_isolation" ON "phone_book_phone";
+ALTER TABLE "phone_book_phone" NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE "phone_book_phone" DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS "tenant_isolation" ON "phone_book";
+ALTER TABLE "phone_book" NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE "phone_book" DISABLE ROW LEVEL SECURITY;
+
+DROP INDEX IF EXISTS "ix_phone_book_tenant_id";
+
+DROP INDEX IF EXISTS "ux_phone_book_phone_number_e164";
+CREATE UNIQUE INDEX IF NOT EXISTS "ux_phone_book_phone_number_e164" ON "phone_book_phone" USING btree("number_e164")
+WHERE "deleted_date_utc" IS NULL;
+
+ALTER TABLE "role_assignment" DROP CONSTRAINT IF EXISTS "pk_role_assignment";
+ALTER TABLE "role_assignment" ADD CONSTRAINT "pk_role_assignment" PRIMARY KEY("subject", "role");
+
+ALTER TABLE "role_assignment" DROP COLUMN IF EXISTS "tenant_id";
+ALTER TABLE "api_key" DROP COLUMN IF EXISTS "tenant_id";
+ALTER TABLE "export_job" DROP COLUMN IF EXISTS "tenant_id";
+ALTER TABLE "phone_book_audit" DROP COLUMN IF EXISTS "tenant_id";
+ALTER TABLE "phone_book_address" DROP COLUMN IF EXISTS "tenant_id";
+ALTER TABLE "phone_book_email" DROP COLUMN IF EXISTS "tenant_id";
+ALTER TABLE "phone_book_phone" DROP COLUMN IF EXISTS "tenant_id";
+ALTER TABLE "phone_book" DROP COLUMN IF EXISTS "tenant_id";
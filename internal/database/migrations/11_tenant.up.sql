@@ -0,0 +1,67 @@
+-- rows of every table belong to one tenant, existing rows are kept in the default tenant.
+-- role and permission are a catalogue shared by every tenant.
+-- new rows take the tenant of the transaction, set by queryable.RunInTransaction,
+-- and inserting without tenant fails on the NOT NULL constraint
+ALTER TABLE "phone_book" ADD COLUMN IF NOT EXISTS "tenant_id" VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE "phone_book" ALTER COLUMN "tenant_id" SET DEFAULT NULLIF(current_setting('app.tenant_id', true), '');
+ALTER TABLE "phone_book_phone" ADD COLUMN IF NOT EXISTS "tenant_id" VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE "phone_book_phone" ALTER COLUMN "tenant_id" SET DEFAULT NULLIF(current_setting('app.tenant_id', true), '');
+ALTER TABLE "phone_book_email" ADD COLUMN IF NOT EXISTS "tenant_id" VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE "phone_book_email" ALTER COLUMN "tenant_id" SET DEFAULT NULLIF(current_setting('app.tenant_id', true), '');
+ALTER TABLE "phone_book_address" ADD COLUMN IF NOT EXISTS "tenant_id" VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE "phone_book_address" ALTER COLUMN "tenant_id" SET DEFAULT NULLIF(current_setting('app.tenant_id', true), '');
+ALTER TABLE "phone_book_audit" ADD COLUMN IF NOT EXISTS "tenant_id" VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE "phone_book_audit" ALTER COLUMN "tenant_id" SET DEFAULT NULLIF(current_setting('app.tenant_id', true), '');
+ALTER TABLE "export_job" ADD COLUMN IF NOT EXISTS "tenant_id" VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE "export_job" ALTER COLUMN "tenant_id" SET DEFAULT NULLIF(current_setting('app.tenant_id', true), '');
+ALTER TABLE "api_key" ADD COLUMN IF NOT EXISTS "tenant_id" VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE "api_key" ALTER COLUMN "tenant_id" SET DEFAULT NULLIF(current_setting('app.tenant_id', true), '');
+ALTER TABLE "role_assignment" ADD COLUMN IF NOT EXISTS "tenant_id" VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE "role_assignment" ALTER COLUMN "tenant_id" SET DEFAULT NULLIF(current_setting('app.tenant_id', true), '');
+ALTER TABLE "role_assignment" DROP CONSTRAINT IF EXISTS "pk_role_assignment";
+ALTER TABLE "role_assignment" ADD CONSTRAINT "pk_role_assignment" PRIMARY KEY("tenant_id", "subject", "role");
+
+-- phone numbers are unique within a tenant
+DROP INDEX IF EXISTS "ux_phone_book_phone_number_e164";
+CREATE UNIQUE INDEX IF NOT EXISTS "ux_phone_book_phone_number_e164" ON "phone_book_phone" USING btree("tenant_id", "number_e164")
+WHERE "deleted_date_utc" IS NULL;
+
+CREATE INDEX IF NOT EXISTS "ix_phone_book_tenant_id" ON "phone_book" USING btree("tenant_id", "created_date_utc", "id");
+
+-- policies are forced so they also apply to the owner of the tables, which the service connects as.
+-- FORCE does not apply to superusers nor to roles with BYPASSRLS, the service role must be neither
+-- or every tenant is visible to every request.
+-- background jobs spanning every tenant set app.bypass_tenant for their transaction
+
+ALTER TABLE "phone_book" ENABLE ROW LEVEL SECURITY;
+ALTER TABLE "phone_book" FORCE ROW LEVEL SECURITY;
+CREATE POLICY "tenant_isolation" ON "phone_book"
+USING ("tenant_id" = current_setting('app.tenant_id', true) OR current_setting('app.bypass_tenant', true) = 'on');
+ALTER TABLE "phone_book_phone" ENABLE ROW LEVEL SECURITY;
+ALTER TABLE "phone_book_phone" FORCE ROW LEVEL SECURITY;
+CREATE POLICY "tenant_isolation" ON "phone_book_phone"
+USING ("tenant_id" = current_setting('app.tenant_id', true) OR current_setting('app.bypass_tenant', true) = 'on');
+ALTER TABLE "phone_book_email" ENABLE ROW LEVEL SECURITY;
+ALTER TABLE "phone_book_email" FORCE ROW LEVEL SECURITY;
+CREATE POLICY "tenant_isolation" ON "phone_book_email"
+USING ("tenant_id" = current_setting('app.tenant_id', true) OR current_setting('app.bypass_tenant', true) = 'on');
+ALTER TABLE "phone_book_address" ENABLE ROW LEVEL SECURITY;
+ALTER TABLE "phone_book_address" FORCE ROW LEVEL SECURITY;
+CREATE POLICY "tenant_isolation" ON "phone_book_address"
+USING ("tenant_id" = current_setting('app.tenant_id', true) OR current_setting('app.bypass_tenant', true) = 'on');
+ALTER TABLE "phone_book_audit" ENABLE ROW LEVEL SECURITY;
+ALTER TABLE "phone_book_audit" FORCE ROW LEVEL SECURITY;
+CREATE POLICY "tenant_isolation" ON "phone_book_audit"
+USING ("tenant_id" = current_setting('app.tenant_id', true) OR current_setting('app.bypass_tenant', true) = 'on');
+ALTER TABLE "export_job" ENABLE ROW LEVEL SECURITY;
+ALTER TABLE "export_job" FORCE ROW LEVEL SECURITY;
+CREATE POLICY "tenant_isolation" ON "export_job"
+USING ("tenant_id" = current_setting('app.tenant_id', true) OR current_setting('app.bypass_tenant', true) = 'on');
+ALTER TABLE "api_key" ENABLE ROW LEVEL SECURITY;
+ALTER TABLE "api_key" FORCE ROW LEVEL SECURITY;
+CREATE POLICY "tenant_isolation" ON "api_key"
+USING ("tenant_id" = current_setting('app.tenant_id', true) OR current_setting('app.bypass_tenant', true) = 'on');
+ALTER TABLE "role_assignment" ENABLE ROW LEVEL SECURITY;
+ALTER TABLE "role_assignment" FORCE ROW LEVEL SECURITY;
+CREATE POLICY "tenant_isolation" ON "role_assignment"
+USING ("tenant_id" = current_setting('app.tenant_id', true) OR current_setting('app.bypass_tenant', true) = 'on');
@@ -0,0 +1,35 @@
+CREATE TABLE IF NOT EXISTS "groups" (
+    "id" UUID NOT NULL,
+    "tenant_id" VARCHAR(64) NOT NULL DEFAULT NULLIF(current_setting('app.tenant_id', true), ''),
+    "name" VARCHAR(100) NOT NULL,
+    "description" VARCHAR(255),
+    "created_date_utc" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "created_by" VARCHAR(255) NOT NULL,
+    "updated_date_utc" TIMESTAMPTZ,
+    "updated_by" VARCHAR(255),
+    CONSTRAINT "pk_groups" PRIMARY KEY("id")
+);
+-- group names are unique within a tenant regardless of case
+CREATE UNIQUE INDEX IF NOT EXISTS "ux_groups_name" ON "groups" USING btree("tenant_id", lower("name"));
+
+CREATE TABLE IF NOT EXISTS "group_member" (
+    "group_id" UUID NOT NULL,
+    "phone_book_id" UUID NOT NULL,
+    "tenant_id" VARCHAR(64) NOT NULL DEFAULT NULLIF(current_setting('app.tenant_id', true), ''),
+    "created_date_utc" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "created_by" VARCHAR(255),
+    CONSTRAINT "pk_group_member" PRIMARY KEY("group_id", "phone_book_id"),
+    CONSTRAINT "fk_group_member_groups" FOREIGN KEY("group_id") REFERENCES "groups"("id") ON DELETE CASCADE,
+    CONSTRAINT "fk_group_member_phone_book" FOREIGN KEY("phone_book_id") REFERENCES "phone_book"("id") ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "ix_group_member_phone_book_id" ON "group_member" USING btree("phone_book_id");
+
+ALTER TABLE "groups" ENABLE ROW LEVEL SECURITY;
+ALTER TABLE "groups" FORCE ROW LEVEL SECURITY;
+CREATE POLICY "tenant_isolation" ON "groups"
+USING ("tenant_id" = current_setting('app.tenant_id', true) OR current_setting('app.bypass_tenant', true) = 'on');
+
+ALTER TABLE "group_member" ENABLE ROW LEVEL SECURITY;
+ALTER TABLE "group_member" FORCE ROW LEVEL SECURITY;
+CREATE POLICY "tenant_isolation" ON "group_member"
+USING ("tenant_id" = current_setting('app.tenant_id', true) OR current_setting('app.bypass_tenant', true) = 'on');
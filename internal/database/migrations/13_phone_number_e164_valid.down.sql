@@ -0,0 +1 @@
+-- numbers cleared by the up migration were not valid E.164, there is nothing to restore
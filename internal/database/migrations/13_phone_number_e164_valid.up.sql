@@ -0,0 +1,9 @@
+-- migration 4 stored every number starting with + as E.164, numbers that are not
+-- valid E.164 go back to NULL like the national numbers it did not normalize.
+-- rows of every tenant are updated, the setting only last for this migration
+SELECT set_config('app.bypass_tenant', 'on', true);
+
+UPDATE "phone_book" SET "phone_number_e164" = NULL
+WHERE "phone_number_e164" !~ '^\+[1-9][0-9]{7,14}$';
+UPDATE "phone_book_phone" SET "number_e164" = NULL
+WHERE "number_e164" !~ '^\+[1-9][0-9]{7,14}$';
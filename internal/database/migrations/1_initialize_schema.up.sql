@@ -8,8 +8,8 @@ CREATE TABLE IF NOT EXISTS "phone_book" (
     "updated_date_utc" TIMESTAMPTZ,
     "updated_by" VARCHAR(255),
     "deleted_date_utc" TIMESTAMPTZ,
-    "deletedby" VARCHAR(255),
+    "deleted_by" VARCHAR(255),
     CONSTRAINT "ak_phone_book_id" UNIQUE("id"),
-    CONSTRAINT "pk_phone_book" PRIMARY KEY("ud")
+    CONSTRAINT "pk_phone_book" PRIMARY KEY("id")
 );
 CREATE INDEX IF NOT EXISTS "ix_phone_book_id" ON "phone_book" USING btree("id");
\ No newline at end of file
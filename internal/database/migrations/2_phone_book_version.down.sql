@@ -0,0 +1 @@
+ALTER TABLE "phone_book" DROP COLUMN IF EXISTS "version";
\ No newline at end of file
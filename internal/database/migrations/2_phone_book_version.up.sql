@@ -0,0 +1 @@
+ALTER TABLE "phone_book" ADD COLUMN IF NOT EXISTS "version" INTEGER NOT NULL DEFAULT 1;
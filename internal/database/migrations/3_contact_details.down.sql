@@ -0,0 +1,3 @@
+drop table "phone_book_address";
+drop table "phone_book_email";
+drop table "phone_book_phone";
@@ -0,0 +1,42 @@
+CREATE TABLE IF NOT EXISTS "phone_book_phone" (
+    "id" UUID NOT NULL,
+    "phone_book_id" UUID NOT NULL,
+    "label" VARCHAR(50) NOT NULL,
+    "number" VARCHAR(255) NOT NULL,
+    "is_primary" BOOLEAN NOT NULL DEFAULT FALSE,
+    CONSTRAINT "pk_phone_book_phone" PRIMARY KEY("id"),
+    CONSTRAINT "fk_phone_book_phone_phone_book" FOREIGN KEY("phone_book_id") REFERENCES "phone_book"("id") ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "ix_phone_book_phone_phone_book_id" ON "phone_book_phone" USING btree("phone_book_id");
+CREATE INDEX IF NOT EXISTS "ix_phone_book_phone_number" ON "phone_book_phone" USING btree("number");
+
+CREATE TABLE IF NOT EXISTS "phone_book_email" (
+    "id" UUID NOT NULL,
+    "phone_book_id" UUID NOT NULL,
+    "label" VARCHAR(50) NOT NULL,
+    "email" VARCHAR(255) NOT NULL,
+    "is_primary" BOOLEAN NOT NULL DEFAULT FALSE,
+    CONSTRAINT "pk_phone_book_email" PRIMARY KEY("id"),
+    CONSTRAINT "fk_phone_book_email_phone_book" FOREIGN KEY("phone_book_id") REFERENCES "phone_book"("id") ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "ix_phone_book_email_phone_book_id" ON "phone_book_email" USING btree("phone_book_id");
+
+CREATE TABLE IF NOT EXISTS "phone_book_address" (
+    "id" UUID NOT NULL,
+    "phone_book_id" UUID NOT NULL,
+    "label" VARCHAR(50) NOT NULL,
+    "address" VARCHAR(255) NOT NULL,
+    "is_primary" BOOLEAN NOT NULL DEFAULT FALSE,
+    CONSTRAINT "pk_phone_book_address" PRIMARY KEY("id"),
+    CONSTRAINT "fk_phone_book_address_phone_book" FOREIGN KEY("phone_book_id") REFERENCES "phone_book"("id") ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "ix_phone_book_address_phone_book_id" ON "phone_book_address" USING btree("phone_book_id");
+
+-- existing single phone number and address become the primary entries
+INSERT INTO "phone_book_phone" ("id", "phone_book_id", "label", "number", "is_primary")
+SELECT md5(random()::text || clock_timestamp()::text || "id"::text)::uuid, "id", 'mobile', "phone_number", TRUE
+FROM "phone_book";
+
+INSERT INTO "phone_book_address" ("id", "phone_book_id", "label", "address", "is_primary")
+SELECT md5(random()::text || clock_timestamp()::text || "id"::text)::uuid, "id", 'home', "address", TRUE
+FROM "phone_book";
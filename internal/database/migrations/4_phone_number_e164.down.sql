@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "ix_phone_book_phone_number_e164";
+ALTER TABLE "phone_book_phone" DROP COLUMN IF EXISTS "number_e164";
+ALTER TABLE "phone_book" DROP COLUMN IF EXISTS "phone_number_e164";
@@ -0,0 +1,16 @@
+ALTER TABLE "phone_book" ADD COLUMN IF NOT EXISTS "phone_number_e164" VARCHAR(16);
+ALTER TABLE "phone_book_phone" ADD COLUMN IF NOT EXISTS "number_e164" VARCHAR(16);
+
+-- numbers already written in a valid international format are normalized here, anything
+-- else is left NULL rather than stored as if it were E.164 or aborting on the column size.
+-- national numbers need the default region and are only normalized by the application
+-- when their contact is next written, until then the unique index does not see them, so
+-- that write is rejected with 409 when another contact of the tenant has the same number
+UPDATE "phone_book" SET "phone_number_e164" = '+' || regexp_replace("phone_number", '[^0-9]', '', 'g')
+WHERE "phone_number" LIKE '+%'
+AND '+' || regexp_replace("phone_number", '[^0-9]', '', 'g') ~ '^\+[1-9][0-9]{7,14}$';
+UPDATE "phone_book_phone" SET "number_e164" = '+' || regexp_replace("number", '[^0-9]', '', 'g')
+WHERE "number" LIKE '+%'
+AND '+' || regexp_replace("number", '[^0-9]', '', 'g') ~ '^\+[1-9][0-9]{7,14}$';
+
+CREATE INDEX IF NOT EXISTS "ix_phone_book_phone_number_e164" ON "phone_book_phone" USING btree("number_e164");
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS "ux_phone_book_phone_number_e164";
+ALTER TABLE "phone_book_phone" DROP COLUMN IF EXISTS "deleted_date_utc";
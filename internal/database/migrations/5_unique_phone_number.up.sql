@@ -0,0 +1,23 @@
+-- phone entries follow the soft delete of their contact so the unique index can skip them
+ALTER TABLE "phone_book_phone" ADD COLUMN IF NOT EXISTS "deleted_date_utc" TIMESTAMPTZ;
+
+UPDATE "phone_book_phone" SET "deleted_date_utc" = "phone_book"."deleted_date_utc"
+FROM "phone_book"
+WHERE "phone_book"."id" = "phone_book_phone"."phone_book_id" AND "phone_book"."deleted_date_utc" IS NOT NULL;
+
+-- existing duplicates keep their raw number, only the oldest entry keeps the normalized one
+UPDATE "phone_book_phone" SET "number_e164" = NULL
+WHERE "id" IN (
+    SELECT "id" FROM (
+        SELECT "phone_book_phone"."id", row_number() OVER (
+            PARTITION BY "number_e164" ORDER BY "phone_book"."created_date_utc", "phone_book"."id"
+        ) AS "rank"
+        FROM "phone_book_phone"
+        JOIN "phone_book" ON "phone_book"."id" = "phone_book_phone"."phone_book_id"
+        WHERE "phone_book_phone"."number_e164" IS NOT NULL AND "phone_book_phone"."deleted_date_utc" IS NULL
+    ) AS "duplicate"
+    WHERE "rank" > 1
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "ux_phone_book_phone_number_e164" ON "phone_book_phone" USING btree("number_e164")
+WHERE "deleted_date_utc" IS NULL;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS "ix_phone_book_phone_number_e164_prefix";
+DROP INDEX IF EXISTS "ix_phone_book_address_trgm";
+DROP INDEX IF EXISTS "ix_phone_book_fullname_trgm";
+DROP INDEX IF EXISTS "ix_phone_book_search";
@@ -0,0 +1,9 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS "ix_phone_book_search" ON "phone_book"
+USING gin(to_tsvector('simple', "fullname" || ' ' || "address"))
+WHERE "deleted_date_utc" IS NULL;
+CREATE INDEX IF NOT EXISTS "ix_phone_book_fullname_trgm" ON "phone_book" USING gin("fullname" gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS "ix_phone_book_address_trgm" ON "phone_book" USING gin("address" gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS "ix_phone_book_phone_number_e164_prefix" ON "phone_book_phone" USING btree("number_e164" varchar_pattern_ops)
+WHERE "deleted_date_utc" IS NULL;
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS "export_job" (
+    "id" UUID NOT NULL,
+    "format" VARCHAR(16) NOT NULL,
+    "filter" JSONB NOT NULL DEFAULT '{}',
+    "status" VARCHAR(16) NOT NULL DEFAULT 'pending',
+    "processed" INTEGER NOT NULL DEFAULT 0,
+    "total" INTEGER,
+    "file_path" TEXT,
+    "error" TEXT,
+    "created_date_utc" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "created_by" VARCHAR(255) NOT NULL,
+    "started_date_utc" TIMESTAMPTZ,
+    "heartbeat_date_utc" TIMESTAMPTZ,
+    "finished_date_utc" TIMESTAMPTZ,
+    CONSTRAINT "pk_export_job" PRIMARY KEY("id")
+);
+
+-- worker pick the oldest job that is pending or whose worker stopped sending heartbeat
+CREATE INDEX IF NOT EXISTS "ix_export_job_status" ON "export_job" USING btree("status", "created_date_utc");
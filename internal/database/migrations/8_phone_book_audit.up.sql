@@ -0,0 +1,13 @@
+-- history is kept when a profile is purged, so there is no foreign key to phone_book
+CREATE TABLE IF NOT EXISTS "phone_book_audit" (
+    "id" BIGSERIAL NOT NULL,
+    "phone_book_id" UUID NOT NULL,
+    "action" VARCHAR(16) NOT NULL,
+    "actor" VARCHAR(255),
+    "changed_fields" TEXT[] NOT NULL DEFAULT '{}',
+    "before" JSONB,
+    "after" JSONB,
+    "created_date_utc" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT "pk_phone_book_audit" PRIMARY KEY("id")
+);
+CREATE INDEX IF NOT EXISTS "ix_phone_book_audit_phone_book_id" ON "phone_book_audit" USING btree("phone_book_id", "id");
@@ -0,0 +1,11 @@
+-- only the sha256 of the secret is stored, the secret is shown once when the key is issued
+CREATE TABLE IF NOT EXISTS "api_key" (
+    "id" UUID NOT NULL,
+    "client_id" VARCHAR(64) NOT NULL,
+    "secret_hash" CHAR(64) NOT NULL,
+    "name" VARCHAR(255) NOT NULL,
+    "created_date_utc" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "revoked_date_utc" TIMESTAMPTZ,
+    CONSTRAINT "pk_api_key" PRIMARY KEY("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "ux_api_key_client_id" ON "api_key" USING btree("client_id");
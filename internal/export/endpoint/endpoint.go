@@ -0,0 +1,43 @@
+package endpoint
+
+import (
+	// internal golang package
+	"context"
+
+	// internal package
+	"phonebook/internal/export"
+	"phonebook/internal/export/model"
+	"phonebook/pkg/queryable"
+
+	// thirdparty package
+	"github.com/go-kit/kit/endpoint"
+)
+
+// Create ...
+func Create(svc *export.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		err = queryable.RunInTransaction(ctx, svc.DB, func(ctx context.Context) error {
+			reqData := request.(*model.Export)
+			response, err = svc.CreateExport(ctx, reqData)
+			return err
+		})
+		return response, err
+	}
+}
+
+// FetchByID ...
+// the file of a finished job is returned instead of its status when download is asked
+func FetchByID(svc *export.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		err = queryable.RunInTransaction(ctx, svc.DB, func(ctx context.Context) error {
+			reqData := request.(*model.GetExport)
+			if reqData.Download {
+				response, err = svc.Download(ctx, reqData.ID)
+			} else {
+				response, err = svc.FetchByID(ctx, reqData.ID)
+			}
+			return err
+		})
+		return response, err
+	}
+}
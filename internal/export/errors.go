@@ -0,0 +1,12 @@
+package export
+
+import (
+	// internal package
+	"phonebook/pkg/httperror"
+)
+
+// error catalogue of export domain
+var (
+	ErrExportNotExist = httperror.New(httperror.NotFound, "export_not_exist", "export does not exist")
+	ErrExportNotReady = httperror.New(httperror.Conflict, "export_not_ready", "export file is not ready")
+)
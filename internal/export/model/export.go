@@ -0,0 +1,118 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// format of export file
+const (
+	FormatCSV   = "csv"
+	FormatJSON  = "json"
+	FormatVCard = "vcard"
+)
+
+// status of export job
+const (
+	StatusPending = "pending"
+	StatusRunning = "running"
+	StatusDone    = "done"
+	StatusFailed  = "failed"
+)
+
+// Filter phone book filter of export, same meaning as the filter of phone book list
+type Filter struct {
+	Fullname    *string     `json:"fullname,omitempty"`
+	PhoneNumber *string     `json:"phone_number,omitempty"`
+	Address     *string     `json:"address,omitempty"`
+	Groups      []uuid.UUID `json:"groups,omitempty"`
+}
+
+// Value store filter as jsonb
+func (f Filter) Value() (driver.Value, error) {
+	return json.Marshal(f)
+}
+
+// Scan read filter from jsonb
+func (f *Filter) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, f)
+	case string:
+		return json.Unmarshal([]byte(v), f)
+	case nil:
+		return nil
+	}
+	return errors.New("unsupported filter type")
+}
+
+// Export export job, the file is written by the worker and kept on local disk
+type Export struct {
+	ID               uuid.UUID  `db:"id" json:"id"`
+	Format           string     `db:"format" json:"format" validate:"required,oneof=csv json vcard"`
+	Filter           Filter     `db:"filter" json:"filter"`
+	Status           string     `db:"status" json:"status"`
+	Processed        int        `db:"processed" json:"processed"`
+	Total            *int       `db:"total" json:"total"`
+	FilePath         *string    `db:"file_path" json:"-"`
+	Error            *string    `db:"error" json:"error,omitempty"`
+	CreatedDateUTC   *time.Time `db:"created_date_utc" json:"created_date_utc"`
+	CreatedBy        *string    `db:"created_by" json:"created_by"`
+	StartedDateUTC   *time.Time `db:"started_date_utc" json:"started_date_utc"`
+	HeartbeatDateUTC *time.Time `db:"heartbeat_date_utc" json:"-"`
+	FinishedDateUTC  *time.Time `db:"finished_date_utc" json:"finished_date_utc"`
+	TenantID         string     `db:"tenant_id" json:"-"`
+
+	DownloadURL *string `db:"-" json:"download_url,omitempty"`
+}
+
+// StatusCode jobs that are not finished yet are accepted
+func (e *Export) StatusCode() int {
+	if e.Status == StatusPending || e.Status == StatusRunning {
+		return http.StatusAccepted
+	}
+	return http.StatusOK
+}
+
+// GetExport ...
+type GetExport struct {
+	ID       uuid.UUID `json:"id" httpurl:"id" validate:"required"`
+	Download bool      `json:"download" httpquery:"download"`
+}
+
+// ExportFile finished export file, streamed as response body
+type ExportFile struct {
+	Path      string
+	Name      string
+	MediaType string
+}
+
+// ContentType ...
+func (f *ExportFile) ContentType() string {
+	return f.MediaType
+}
+
+// Headers ...
+func (f *ExportFile) Headers() http.Header {
+	return http.Header{
+		"Content-Disposition": []string{`attachment; filename="` + f.Name + `"`},
+	}
+}
+
+// WriteTo copy the file into w
+func (f *ExportFile) WriteTo(w io.Writer) (int64, error) {
+	file, err := os.Open(f.Path)
+	if nil != err {
+		return 0, err
+	}
+	defer file.Close()
+
+	return io.Copy(w, file)
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	// internal golang package
+	"context"
+	"database/sql"
+	"time"
+
+	// internal package
+	"phonebook/internal/export/model"
+	"phonebook/internal/global"
+	"phonebook/pkg/queryable"
+	"phonebook/pkg/tenant"
+
+	// thirdparty package
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository struct {
+	db *sqlx.DB
+}
+
+func NewPostgres(db *sqlx.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// CreateExport , enqueue export job
+func (r *Repository) CreateExport(ctx context.Context, data *model.Export) error {
+	_, err := global.GetQuery(ctx, r.db).NamedExecContext(ctx, `INSERT INTO export_job (id, format, filter, status, created_by)
+	VALUES (:id, :format, :filter, :status, :created_by)`, data)
+	return err
+}
+
+// FetchByID , get one export job, nil when it does not exist
+func (r *Repository) FetchByID(ctx context.Context, id uuid.UUID) (*model.Export, error) {
+	result := &model.Export{}
+	err := global.GetQuery(ctx, r.db).GetContext(ctx, result, `SELECT * FROM export_job WHERE id = $1`, id)
+	if sql.ErrNoRows == err {
+		return nil, nil
+	}
+
+	if nil != err {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ClaimExport , mark the oldest waiting job as running and return it, nil when there is none.
+// Running jobs whose heartbeat is older than staleAfter were left by a stopped worker and are claimed again.
+// Jobs of every tenant are claimed
+func (r *Repository) ClaimExport(ctx context.Context, staleAfter time.Duration) (result *model.Export, err error) {
+	err = queryable.RunInTransaction(tenant.System(ctx), r.db, func(ctx context.Context) error {
+		result = &model.Export{}
+		return global.GetQuery(ctx, r.db).GetContext(ctx, result, `UPDATE export_job SET
+			status = 'running',
+			processed = 0,
+			started_date_utc = CURRENT_TIMESTAMP,
+			heartbeat_date_utc = CURRENT_TIMESTAMP
+		WHERE id = (
+			SELECT id FROM export_job
+			WHERE status = 'pending'
+			OR (status = 'running' AND heartbeat_date_utc < CURRENT_TIMESTAMP - $1 * INTERVAL '1 second')
+			ORDER BY created_date_utc
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`, staleAfter.Seconds())
+	})
+	if sql.ErrNoRows == err {
+		return nil, nil
+	}
+
+	if nil != err {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// UpdateProgress , record processed rows of running job, which also serve as its heartbeat
+func (r *Repository) UpdateProgress(ctx context.Context, id uuid.UUID, processed int, total int) error {
+	return queryable.RunInTransaction(ctx, r.db, func(ctx context.Context) error {
+		_, err := global.GetQuery(ctx, r.db).ExecContext(ctx, `UPDATE export_job SET
+			processed = $2, total = $3, heartbeat_date_utc = CURRENT_TIMESTAMP
+		WHERE id = $1`, id, processed, total)
+		return err
+	})
+}
+
+// FinishExport , record final status, file and error of job
+func (r *Repository) FinishExport(ctx context.Context, data *model.Export) error {
+	return queryable.RunInTransaction(ctx, r.db, func(ctx context.Context) error {
+		_, err := global.GetQuery(ctx, r.db).NamedExecContext(ctx, `UPDATE export_job SET
+			status = :status, processed = :processed, total = :total, file_path = :file_path, error = :error,
+			finished_date_utc = CURRENT_TIMESTAMP, heartbeat_date_utc = CURRENT_TIMESTAMP
+		WHERE id = :id`, data)
+		return err
+	})
+}
+
+// ReleaseExport , put running job back to pending so the next worker claim it without
+// waiting for its heartbeat to be stale
+func (r *Repository) ReleaseExport(ctx context.Context, id uuid.UUID) error {
+	return queryable.RunInTransaction(ctx, r.db, func(ctx context.Context) error {
+		_, err := global.GetQuery(ctx, r.db).ExecContext(ctx, `UPDATE export_job SET
+			status = 'pending', processed = 0, started_date_utc = NULL, heartbeat_date_utc = NULL
+		WHERE id = $1 AND status = 'running'`, id)
+		return err
+	})
+}
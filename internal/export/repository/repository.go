@@ -0,0 +1,21 @@
+package repository
+
+import (
+	// internal golang package
+	"context"
+	"time"
+
+	// internal package
+	"phonebook/internal/export/model"
+
+	"github.com/google/uuid"
+)
+
+type Interface interface {
+	CreateExport(ctx context.Context, data *model.Export) error
+	FetchByID(ctx context.Context, id uuid.UUID) (*model.Export, error)
+	ClaimExport(ctx context.Context, staleAfter time.Duration) (*model.Export, error)
+	UpdateProgress(ctx context.Context, id uuid.UUID, processed int, total int) error
+	FinishExport(ctx context.Context, data *model.Export) error
+	ReleaseExport(ctx context.Context, id uuid.UUID) error
+}
@@ -0,0 +1,99 @@
+package export
+
+import (
+	// internal golang package
+	"context"
+
+	// internal package
+	"phonebook/internal/export/model"
+	"phonebook/internal/export/repository"
+	"phonebook/internal/phonebook"
+	"phonebook/pkg/auth"
+
+	// thirdparty package
+	"github.com/go-kit/kit/log"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type Service struct {
+	Actor     string
+	Logger    log.Logger
+	DB        *sqlx.DB
+	repo      repository.Interface
+	phonebook *phonebook.Service
+}
+
+func NewService(db *sqlx.DB, repo repository.Interface, phonebook *phonebook.Service, actor string, logger log.Logger) *Service {
+	return &Service{
+		Actor:     actor,
+		Logger:    logger,
+		DB:        db,
+		repo:      repo,
+		phonebook: phonebook,
+	}
+}
+
+// CreateExport enqueue export job, the file is written later by the worker
+func (svc *Service) CreateExport(ctx context.Context, data *model.Export) (*model.Export, error) {
+	id, err := uuid.NewRandom()
+	if nil != err {
+		return nil, err
+	}
+
+	actor := svc.actor(ctx)
+	data.ID = id
+	data.Status = model.StatusPending
+	data.CreatedBy = &actor
+
+	err = svc.repo.CreateExport(ctx, data)
+	if nil != err {
+		return nil, err
+	}
+
+	return svc.FetchByID(ctx, id)
+}
+
+// FetchByID fetching status and progress of export job
+func (svc *Service) FetchByID(ctx context.Context, id uuid.UUID) (*model.Export, error) {
+	result, err := svc.repo.FetchByID(ctx, id)
+	if nil != err {
+		return nil, err
+	}
+
+	if nil == result {
+		return nil, ErrExportNotExist
+	}
+
+	if result.Status == model.StatusDone {
+		url := "/v1/exports/" + result.ID.String() + "?download=true"
+		result.DownloadURL = &url
+	}
+
+	return result, nil
+}
+
+// Download file of finished export job
+func (svc *Service) Download(ctx context.Context, id uuid.UUID) (*model.ExportFile, error) {
+	result, err := svc.FetchByID(ctx, id)
+	if nil != err {
+		return nil, err
+	}
+
+	if result.Status != model.StatusDone || nil == result.FilePath {
+		return nil, ErrExportNotReady.WithDetail("status", result.Status)
+	}
+
+	format := formats[result.Format]
+	return &model.ExportFile{
+		Path:      *result.FilePath,
+		Name:      "phonebook-" + result.ID.String() + format.extension,
+		MediaType: format.mediaType,
+	}, nil
+}
+
+// actor name recorded on created_by, the authenticated principal
+// or the name of the process for background work
+func (svc *Service) actor(ctx context.Context) string {
+	return auth.Actor(ctx, svc.Actor)
+}
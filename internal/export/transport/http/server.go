@@ -0,0 +1,50 @@
+package http
+
+import (
+	"net/http"
+
+	"phonebook/internal/export"
+	"phonebook/internal/export/endpoint"
+	"phonebook/internal/export/model"
+	"phonebook/pkg/server"
+
+	kitendpoint "github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	kithttp "github.com/go-kit/kit/transport/http"
+)
+
+func Create(svc *export.Service, logger log.Logger, opts []kithttp.ServerOption, middlewares ...kitendpoint.Middleware) http.Handler {
+	end := endpoint.Create(svc)
+	var serverLogger *server.Logger
+	if nil != logger {
+		serverLogger = &server.Logger{
+			Logger:    logger,
+			Namespace: "export",
+			Subsystem: "create_export",
+			Action:    "POST",
+		}
+	}
+	return server.NewHTTPServer(end, server.HTTPOption{
+		DecodeModel: &model.Export{},
+		Logger:      serverLogger,
+		Middlewares: middlewares,
+	}, opts...)
+}
+
+func FetchByID(svc *export.Service, logger log.Logger, opts []kithttp.ServerOption, middlewares ...kitendpoint.Middleware) http.Handler {
+	end := endpoint.FetchByID(svc)
+	var serverLogger *server.Logger
+	if nil != logger {
+		serverLogger = &server.Logger{
+			Logger:    logger,
+			Namespace: "export",
+			Subsystem: "get_export",
+			Action:    "GET",
+		}
+	}
+	return server.NewHTTPServer(end, server.HTTPOption{
+		DecodeModel: &model.GetExport{},
+		Logger:      serverLogger,
+		Middlewares: middlewares,
+	}, opts...)
+}
@@ -0,0 +1,168 @@
+package export
+
+import (
+	// internal golang package
+	"bufio"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	// internal package
+	"phonebook/config"
+	"phonebook/internal/export/model"
+	pbmodel "phonebook/internal/phonebook/model"
+	"phonebook/pkg/queryable"
+	"phonebook/pkg/tenant"
+)
+
+// Work run export jobs one at a time until ctx is done, the jobs table is polled
+// every ExportPollInterval while no job is waiting. Configuration is read again for
+// every job so a reload takes effect without restarting the worker
+func (svc *Service) Work(ctx context.Context) error {
+	for {
+		if nil != ctx.Err() {
+			return nil
+		}
+
+		cfg, err := config.Get()
+		if nil != err {
+			return err
+		}
+
+		job, err := svc.repo.ClaimExport(ctx, cfg.ExportStaleAfter)
+		if nil != err {
+			svc.Logger.Log("action", "claim_export", "err", err)
+		}
+
+		if nil != job {
+			svc.process(ctx, cfg, job)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(cfg.ExportPollInterval):
+		}
+	}
+}
+
+// shutdownTimeout bound of the update recording a job once the worker is stopping
+const shutdownTimeout = 5 * time.Second
+
+// process write file of job and record its outcome. A job interrupted by shutdown
+// is put back to pending, writeFile already removed its partial file
+func (svc *Service) process(ctx context.Context, cfg *config.Config, job *model.Export) {
+	err := svc.writeFile(tenant.NewContext(ctx, job.TenantID), cfg, job)
+
+	interrupted := nil != err && nil != ctx.Err()
+
+	// ctx of the worker is done at shutdown, the outcome is recorded on a context of its own
+	if nil != ctx.Err() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+	}
+	ctx = tenant.NewContext(ctx, job.TenantID)
+
+	if interrupted {
+		err = svc.repo.ReleaseExport(ctx, job.ID)
+		if nil != err {
+			svc.Logger.Log("action", "release_export", "id", job.ID, "err", err)
+		}
+		return
+	}
+
+	job.Status = model.StatusDone
+	if nil != err {
+		svc.Logger.Log("action", "export", "id", job.ID, "err", err)
+		message := err.Error()
+		job.Status = model.StatusFailed
+		job.Error = &message
+		job.FilePath = nil
+	}
+
+	err = svc.repo.FinishExport(ctx, job)
+	if nil != err {
+		svc.Logger.Log("action", "finish_export", "id", job.ID, "err", err)
+	}
+}
+
+// writeFile stream every matching profile into the export file, the file is written
+// under a temporary name and renamed once it is complete
+func (svc *Service) writeFile(ctx context.Context, cfg *config.Config, job *model.Export) error {
+	format, ok := formats[job.Format]
+	if !ok {
+		return errors.New("unsupported export format " + job.Format)
+	}
+
+	filter := &pbmodel.GetPhoneList{
+		Fullname:    job.Filter.Fullname,
+		PhoneNumber: job.Filter.PhoneNumber,
+		Address:     job.Filter.Address,
+		Groups:      job.Filter.Groups,
+	}
+
+	var total int
+	err := queryable.RunInTransaction(ctx, svc.DB, func(ctx context.Context) (err error) {
+		total, err = svc.phonebook.CountData(ctx, filter)
+		return err
+	})
+	if nil != err {
+		return err
+	}
+
+	err = os.MkdirAll(cfg.ExportDir, 0750)
+	if nil != err {
+		return err
+	}
+
+	path := filepath.Join(cfg.ExportDir, job.ID.String()+format.extension)
+	partial := path + ".part"
+
+	file, err := os.Create(partial)
+	if nil != err {
+		return err
+	}
+	// nothing is left behind when writing fails or is interrupted, after the rename
+	// there is nothing to remove
+	defer os.Remove(partial)
+	defer file.Close()
+
+	buffered := bufio.NewWriter(file)
+	writer := format.writer(buffered)
+
+	processed := 0
+	err = svc.phonebook.StreamData(ctx, filter, cfg.ExportChunkSize, func(items []*pbmodel.PhoneBook) error {
+		if err := writer.Write(items); nil != err {
+			return err
+		}
+
+		processed += len(items)
+		return svc.repo.UpdateProgress(ctx, job.ID, processed, total)
+	})
+	if nil != err {
+		return err
+	}
+
+	if err = writer.Close(); nil != err {
+		return err
+	}
+	if err = buffered.Flush(); nil != err {
+		return err
+	}
+	if err = file.Close(); nil != err {
+		return err
+	}
+	if err = os.Rename(partial, path); nil != err {
+		return err
+	}
+
+	// rows may change while streaming, the file is the source of truth
+	job.Processed = processed
+	job.Total = &processed
+	job.FilePath = &path
+	return nil
+}
@@ -0,0 +1,118 @@
+package export
+
+import (
+	// internal golang package
+	"encoding/csv"
+	"encoding/json"
+	"io"
+
+	// internal package
+	"phonebook/internal/export/model"
+	pbmodel "phonebook/internal/phonebook/model"
+	"phonebook/pkg/vcard"
+)
+
+// fileWriter write profiles of one export file, chunk by chunk
+type fileWriter interface {
+	Write(items []*pbmodel.PhoneBook) error
+	Close() error
+}
+
+type fileFormat struct {
+	extension string
+	mediaType string
+	writer    func(w io.Writer) fileWriter
+}
+
+var formats = map[string]fileFormat{
+	model.FormatCSV: {".csv", "text/csv; charset=utf-8", func(w io.Writer) fileWriter {
+		return &csvWriter{w: csv.NewWriter(w)}
+	}},
+	model.FormatJSON: {".json", "application/json; charset=utf-8", func(w io.Writer) fileWriter {
+		return &jsonWriter{w: w}
+	}},
+	model.FormatVCard: {".vcf", vcard.MediaType + "; charset=utf-8", func(w io.Writer) fileWriter {
+		return &vcardWriter{e: vcard.NewEncoder(w, vcard.Version4)}
+	}},
+}
+
+type csvWriter struct {
+	w      *csv.Writer
+	header bool
+}
+
+func (c *csvWriter) Write(items []*pbmodel.PhoneBook) error {
+	if !c.header {
+		c.header = true
+		if err := c.w.Write(pbmodel.CSVHeader); nil != err {
+			return err
+		}
+	}
+
+	for _, item := range items {
+		if err := c.w.Write(item.CSVRecord()); nil != err {
+			return err
+		}
+	}
+
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvWriter) Close() error {
+	return c.Write(nil)
+}
+
+// jsonWriter write one json array, items are encoded as they arrive
+type jsonWriter struct {
+	w     io.Writer
+	count int
+}
+
+func (j *jsonWriter) Write(items []*pbmodel.PhoneBook) error {
+	for _, item := range items {
+		sep := ","
+		if j.count == 0 {
+			sep = "["
+		}
+		j.count++
+
+		raw, err := json.Marshal(item)
+		if nil != err {
+			return err
+		}
+		if _, err = io.WriteString(j.w, sep); nil != err {
+			return err
+		}
+		if _, err = j.w.Write(raw); nil != err {
+			return err
+		}
+	}
+	return nil
+}
+
+func (j *jsonWriter) Close() error {
+	end := "]\n"
+	if j.count == 0 {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(j.w, end)
+	return err
+}
+
+type vcardWriter struct {
+	e *vcard.Encoder
+}
+
+func (v *vcardWriter) Write(items []*pbmodel.PhoneBook) error {
+	for _, item := range items {
+		if err := v.e.Encode(item.Card()); nil != err {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *vcardWriter) Close() error {
+	return nil
+}
@@ -4,8 +4,7 @@ import (
 	// internal golang package
 
 	"context"
-	"database/sql"
-	"strconv"
+	"time"
 
 	// internal package
 	"phonebook/config"
@@ -13,41 +12,59 @@ import (
 	// thirdparty package
 	db "phonebook/pkg/queryable"
 
+	"github.com/go-kit/kit/log"
 	"github.com/jmoiron/sqlx"
 )
 
-// DB [singleton] global instance of sqlx
-func DB() *sqlx.DB {
-	cfg, err := config.Get()
-	if err != nil {
-		panic(err)
-	}
-	con, err := sql.Open(cfg.DBType, cfg.DBConnectionString)
-	if err != nil {
-		panic(err)
-	}
+// maxConnectBackoff cap of the wait between two connection attempts
+const maxConnectBackoff = 30 * time.Second
 
-	openConn, err := strconv.Atoi(cfg.MaxOpenCon)
+// OpenDB create the pool shared by the whole process and wait until the database
+// answers, retrying DBConnectAttempts times with a doubling backoff. The caller own
+// the pool and close it on shutdown
+func OpenDB(ctx context.Context, cfg *config.Config, logger log.Logger) (*sqlx.DB, error) {
+	con, err := sqlx.Open(cfg.DBType, cfg.DBConnectionString)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
-	idleConn, err := strconv.Atoi(cfg.MaxIdleCon)
-	if err != nil {
-		panic(err)
-	}
+	con.SetMaxIdleConns(cfg.MaxIdleCon)
+	con.SetMaxOpenConns(cfg.MaxOpenCon)
+	con.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+
+	backoff := cfg.DBConnectBackoff
+	for attempt := 1; ; attempt++ {
+		err = con.PingContext(ctx)
+		if err == nil {
+			break
+		}
+
+		if attempt >= cfg.DBConnectAttempts {
+			con.Close()
+			return nil, err
+		}
 
-	con.SetMaxIdleConns(idleConn)
-	con.SetMaxOpenConns(openConn)
+		logger.Log("database", "ping", "attempt", attempt, "retry_in", backoff, "err", err)
+		select {
+		case <-ctx.Done():
+			con.Close()
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		if backoff *= 2; backoff > maxConnectBackoff {
+			backoff = maxConnectBackoff
+		}
+	}
 
-	sqlxDB := sqlx.NewDb(con, cfg.DBType)
-	return sqlxDB
+	return con, nil
 }
 
-func GetQuery(ctx context.Context) *db.Queryable {
+// GetQuery queryable of the transaction carried by ctx, pool when there is none
+func GetQuery(ctx context.Context, pool *sqlx.DB) *db.Queryable {
 	q, ok := db.QueryableFromContext(ctx)
 	if !ok {
-		qctx := db.NewQueryable(DB())
+		qctx := db.NewQueryable(pool)
 		return &qctx
 	}
 
@@ -0,0 +1,42 @@
+package global
+
+import (
+	// internal golang package
+	"context"
+	"testing"
+
+	// thirdparty package
+	db "phonebook/pkg/queryable"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func TestGetQuery(t *testing.T) {
+	pool := &sqlx.DB{}
+	tx := &sqlx.Tx{}
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want db.Q
+	}{
+		{
+			name: "pool outside of a transaction",
+			ctx:  context.Background(),
+			want: pool,
+		},
+		{
+			name: "transaction carried by context",
+			ctx:  db.NewQueryableContext(context.Background(), db.NewQueryable(tx)),
+			want: tx,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := GetQuery(test.ctx, pool).Q(); got != test.want {
+				t.Errorf("got %T %p, want %T %p", got, got, test.want, test.want)
+			}
+		})
+	}
+}
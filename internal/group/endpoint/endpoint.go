@@ -0,0 +1,105 @@
+package endpoint
+
+import (
+	// internal golang package
+	"context"
+	"database/sql"
+
+	// internal package
+	"phonebook/internal/group"
+	"phonebook/internal/group/model"
+	"phonebook/pkg/queryable"
+
+	// thirdparty package
+	"github.com/go-kit/kit/endpoint"
+)
+
+// endpoints passing these options only work in the database, an attempt rolled back on a
+// serialization failure leave nothing behind and is run again. Writes read the row they
+// change first, repeatable read turn a concurrent change of it into such a failure
+var (
+	read  = []queryable.TxOption{queryable.ReadOnly(), queryable.Retry(queryable.DefaultRetries, queryable.DefaultBackoff)}
+	write = []queryable.TxOption{queryable.Isolation(sql.LevelRepeatableRead), queryable.Retry(queryable.DefaultRetries, queryable.DefaultBackoff)}
+)
+
+// List ...
+func List(svc *group.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		err = queryable.RunInTransaction(ctx, svc.DB, func(ctx context.Context) error {
+			response, err = svc.ListData(ctx)
+			return err
+		}, read...)
+		return response, err
+	}
+}
+
+// Create ...
+func Create(svc *group.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		err = queryable.RunInTransaction(ctx, svc.DB, func(ctx context.Context) error {
+			reqData := request.(*model.Group)
+			response, err = svc.CreateData(ctx, reqData)
+			return err
+		}, write...)
+		return response, err
+	}
+}
+
+// FetchByID ...
+func FetchByID(svc *group.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		err = queryable.RunInTransaction(ctx, svc.DB, func(ctx context.Context) error {
+			reqData := request.(*model.GetGroup)
+			response, err = svc.FetchByID(ctx, reqData.ID)
+			return err
+		}, read...)
+		return response, err
+	}
+}
+
+// Update ...
+func Update(svc *group.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		err = queryable.RunInTransaction(ctx, svc.DB, func(ctx context.Context) error {
+			reqData := request.(*model.Group)
+			response, err = svc.UpdateData(ctx, reqData)
+			return err
+		}, write...)
+		return response, err
+	}
+}
+
+// Remove ...
+func Remove(svc *group.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		err = queryable.RunInTransaction(ctx, svc.DB, func(ctx context.Context) error {
+			reqData := request.(*model.GetGroup)
+			return svc.RemoveData(ctx, reqData.ID)
+		}, write...)
+		return nil, err
+	}
+}
+
+// AddMembers ...
+func AddMembers(svc *group.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		err = queryable.RunInTransaction(ctx, svc.DB, func(ctx context.Context) error {
+			reqData := request.(*model.Members)
+			response, err = svc.AddMembers(ctx, reqData)
+			return err
+		}, write...)
+		return response, err
+	}
+}
+
+// RemoveMembers ...
+func RemoveMembers(svc *group.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		err = queryable.RunInTransaction(ctx, svc.DB, func(ctx context.Context) error {
+			reqData := request.(*model.Members)
+			response, err = svc.RemoveMembers(ctx, reqData)
+			return err
+		}, write...)
+		return response, err
+	}
+}
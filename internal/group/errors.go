@@ -0,0 +1,13 @@
+package group
+
+import (
+	// internal package
+	"phonebook/pkg/httperror"
+)
+
+// error catalogue of group domain
+var (
+	ErrGroupNotExist  = httperror.New(httperror.NotFound, "group_not_exist", "group does not exist")
+	ErrGroupNameTaken = httperror.New(httperror.Conflict, "group_name_taken", "group name is already used")
+	ErrMemberNotExist = httperror.New(httperror.Invalid, "member_not_exist", "contact does not exist")
+)
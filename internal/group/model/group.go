@@ -0,0 +1,41 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Group named set of contacts, a contact may belong to any number of groups
+type Group struct {
+	ID             uuid.UUID  `db:"id" json:"id" httpurl:"id"`
+	Name           *string    `db:"name" json:"name" validate:"required,max=100"`
+	Description    *string    `db:"description" json:"description" validate:"omitempty,max=255"`
+	MemberCount    int        `db:"member_count" json:"member_count"`
+	CreatedDateUTC *time.Time `db:"created_date_utc" json:"created_date_utc"`
+	CreatedBy      *string    `db:"created_by" json:"created_by"`
+	UpdatedDateUTC *time.Time `db:"updated_date_utc" json:"updated_date_utc"`
+	UpdatedBy      *string    `db:"updated_by" json:"updated_by"`
+}
+
+// GetGroup ...
+type GetGroup struct {
+	ID uuid.UUID `json:"id" httpurl:"id" validate:"required"`
+}
+
+// GroupList every group of the tenant ordered by name
+type GroupList struct {
+	Items []*Group `json:"items"`
+}
+
+// Members contacts added to or removed from group in one request
+type Members struct {
+	GroupID uuid.UUID   `json:"-" httpurl:"id" validate:"required"`
+	IDs     []uuid.UUID `json:"ids" validate:"required,min=1,max=1000"`
+}
+
+// MembersResult number of memberships actually changed, contacts already
+// in the group or already out of it are not counted
+type MembersResult struct {
+	Count int `json:"count"`
+}
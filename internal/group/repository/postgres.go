@@ -0,0 +1,145 @@
+package repository
+
+import (
+	// internal golang package
+	"context"
+	"database/sql"
+	"errors"
+
+	// internal package
+	"phonebook/internal/global"
+	"phonebook/internal/group/model"
+
+	// thirdparty package
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const (
+	uniqueViolation      = "23505"
+	uniqueNameConstraint = "ux_groups_name"
+)
+
+// ErrDuplicateName name of group is used by another group of the tenant
+var ErrDuplicateName = errors.New("group name is already used")
+
+// groupColumns group columns with the number of its contacts that are not deleted
+const groupColumns = `id, name, description, created_date_utc, created_by, updated_date_utc, updated_by,
+	(SELECT count(*) FROM group_member
+		JOIN phone_book ON phone_book.id = group_member.phone_book_id AND phone_book.deleted_date_utc IS NULL
+		WHERE group_member.group_id = groups.id) AS member_count`
+
+type Repository struct {
+	db *sqlx.DB
+}
+
+func NewPostgres(db *sqlx.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// ListGroups , get every group ordered by name
+func (r *Repository) ListGroups(ctx context.Context) ([]*model.Group, error) {
+	result := make([]*model.Group, 0)
+	err := global.GetQuery(ctx, r.db).SelectContext(ctx, &result, `SELECT `+groupColumns+` FROM groups ORDER BY lower(name), id`)
+	return result, err
+}
+
+// FetchByID , get one group, nil when it does not exist
+func (r *Repository) FetchByID(ctx context.Context, id uuid.UUID) (*model.Group, error) {
+	result := &model.Group{}
+	err := global.GetQuery(ctx, r.db).GetContext(ctx, result, `SELECT `+groupColumns+` FROM groups WHERE id = $1`, id)
+	if sql.ErrNoRows == err {
+		return nil, nil
+	}
+
+	if nil != err {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// CreateGroup , adding new group
+func (r *Repository) CreateGroup(ctx context.Context, data *model.Group) error {
+	_, err := global.GetQuery(ctx, r.db).NamedExecContext(ctx, `INSERT INTO groups (id, name, description, created_by)
+	VALUES (:id, :name, :description, :created_by)`, data)
+	return translateError(err)
+}
+
+// UpdateGroup , replace name and description of group, updated is false when it does not exist
+func (r *Repository) UpdateGroup(ctx context.Context, data *model.Group) (bool, error) {
+	res, err := global.GetQuery(ctx, r.db).NamedExecContext(ctx, `UPDATE groups SET
+		name = :name, description = :description,
+		updated_date_utc = CURRENT_TIMESTAMP, updated_by = :updated_by
+	WHERE id = :id`, data)
+	if nil != err {
+		return false, translateError(err)
+	}
+
+	count, err := res.RowsAffected()
+	return count > 0, err
+}
+
+// RemoveGroup , delete group with its memberships, the contacts are kept
+func (r *Repository) RemoveGroup(ctx context.Context, id uuid.UUID) (bool, error) {
+	res, err := global.GetQuery(ctx, r.db).ExecContext(ctx, `DELETE FROM groups WHERE id = $1`, id)
+	if nil != err {
+		return false, err
+	}
+
+	count, err := res.RowsAffected()
+	return count > 0, err
+}
+
+// MissingContacts , return the ids that are not contacts, deleted contacts are missing too
+func (r *Repository) MissingContacts(ctx context.Context, ids []uuid.UUID) ([]uuid.UUID, error) {
+	result := make([]uuid.UUID, 0)
+	err := global.GetQuery(ctx, r.db).SelectContext(ctx, &result, `SELECT id FROM unnest(CAST($1 AS uuid[])) AS requested(id)
+	WHERE NOT EXISTS (
+		SELECT 1 FROM phone_book WHERE phone_book.id = requested.id AND phone_book.deleted_date_utc IS NULL
+	)`, idArray(ids))
+	return result, err
+}
+
+// AddMembers , add contacts to group and return how many were not members yet
+func (r *Repository) AddMembers(ctx context.Context, groupID uuid.UUID, ids []uuid.UUID, actor string) (int, error) {
+	res, err := global.GetQuery(ctx, r.db).ExecContext(ctx, `INSERT INTO group_member (group_id, phone_book_id, created_by)
+	SELECT $1, id FROM unnest(CAST($2 AS uuid[])) AS requested(id)
+	ON CONFLICT DO NOTHING`, groupID, idArray(ids), actor)
+	if nil != err {
+		return 0, err
+	}
+
+	count, err := res.RowsAffected()
+	return int(count), err
+}
+
+// RemoveMembers , remove contacts from group and return how many were members
+func (r *Repository) RemoveMembers(ctx context.Context, groupID uuid.UUID, ids []uuid.UUID) (int, error) {
+	res, err := global.GetQuery(ctx, r.db).ExecContext(ctx, `DELETE FROM group_member
+	WHERE group_id = $1 AND phone_book_id = ANY(CAST($2 AS uuid[]))`, groupID, idArray(ids))
+	if nil != err {
+		return 0, err
+	}
+
+	count, err := res.RowsAffected()
+	return int(count), err
+}
+
+func idArray(ids []uuid.UUID) pq.StringArray {
+	result := make(pq.StringArray, 0, len(ids))
+	for _, id := range ids {
+		result = append(result, id.String())
+	}
+	return result
+}
+
+// translateError turn unique violation of group name into ErrDuplicateName
+func translateError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation && pqErr.Constraint == uniqueNameConstraint {
+		return ErrDuplicateName
+	}
+	return err
+}
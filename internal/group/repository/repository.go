@@ -0,0 +1,22 @@
+package repository
+
+import (
+	// internal golang package
+	"context"
+
+	// internal package
+	"phonebook/internal/group/model"
+
+	"github.com/google/uuid"
+)
+
+type Interface interface {
+	ListGroups(ctx context.Context) ([]*model.Group, error)
+	FetchByID(ctx context.Context, id uuid.UUID) (*model.Group, error)
+	CreateGroup(ctx context.Context, data *model.Group) error
+	UpdateGroup(ctx context.Context, data *model.Group) (bool, error)
+	RemoveGroup(ctx context.Context, id uuid.UUID) (bool, error)
+	MissingContacts(ctx context.Context, ids []uuid.UUID) ([]uuid.UUID, error)
+	AddMembers(ctx context.Context, groupID uuid.UUID, ids []uuid.UUID, actor string) (int, error)
+	RemoveMembers(ctx context.Context, groupID uuid.UUID, ids []uuid.UUID) (int, error)
+}
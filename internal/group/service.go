@@ -0,0 +1,184 @@
+package group
+
+import (
+	// internal golang package
+	"context"
+	"strings"
+
+	// internal package
+	"phonebook/internal/group/model"
+	"phonebook/internal/group/repository"
+	"phonebook/pkg/auth"
+	"phonebook/pkg/httperror"
+
+	// thirdparty package
+	"github.com/go-kit/kit/log"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type Service struct {
+	Actor  string
+	Logger log.Logger
+	DB     *sqlx.DB
+	repo   repository.Interface
+}
+
+func NewService(db *sqlx.DB, repo repository.Interface, actor string, logger log.Logger) *Service {
+	return &Service{
+		Actor:  actor,
+		Logger: logger,
+		DB:     db,
+		repo:   repo,
+	}
+}
+
+// ListData every group of the tenant
+func (svc *Service) ListData(ctx context.Context) (*model.GroupList, error) {
+	items, err := svc.repo.ListGroups(ctx)
+	if nil != err {
+		return nil, err
+	}
+
+	return &model.GroupList{Items: items}, nil
+}
+
+// FetchByID ...
+func (svc *Service) FetchByID(ctx context.Context, id uuid.UUID) (*model.Group, error) {
+	result, err := svc.repo.FetchByID(ctx, id)
+	if nil != err {
+		return nil, err
+	}
+
+	if nil == result {
+		return nil, ErrGroupNotExist
+	}
+
+	return result, nil
+}
+
+// CreateData adding new group
+func (svc *Service) CreateData(ctx context.Context, data *model.Group) (*model.Group, error) {
+	id, err := uuid.NewRandom()
+	if nil != err {
+		return nil, err
+	}
+
+	if err = normalize(data); nil != err {
+		return nil, err
+	}
+
+	actor := svc.actor(ctx)
+	data.ID = id
+	data.CreatedBy = &actor
+
+	err = svc.repo.CreateGroup(ctx, data)
+	if nil != err {
+		return nil, conflict(err)
+	}
+
+	return svc.FetchByID(ctx, id)
+}
+
+// UpdateData replace name and description of group
+func (svc *Service) UpdateData(ctx context.Context, data *model.Group) (*model.Group, error) {
+	if err := normalize(data); nil != err {
+		return nil, err
+	}
+
+	actor := svc.actor(ctx)
+	data.UpdatedBy = &actor
+
+	updated, err := svc.repo.UpdateGroup(ctx, data)
+	if nil != err {
+		return nil, conflict(err)
+	}
+
+	if !updated {
+		return nil, ErrGroupNotExist
+	}
+
+	return svc.FetchByID(ctx, data.ID)
+}
+
+// RemoveData delete group, its contacts are kept
+func (svc *Service) RemoveData(ctx context.Context, id uuid.UUID) error {
+	removed, err := svc.repo.RemoveGroup(ctx, id)
+	if nil != err {
+		return err
+	}
+
+	if !removed {
+		return ErrGroupNotExist
+	}
+
+	return nil
+}
+
+// AddMembers add contacts to group, nothing is added when one of them does not exist
+func (svc *Service) AddMembers(ctx context.Context, data *model.Members) (*model.MembersResult, error) {
+	_, err := svc.FetchByID(ctx, data.GroupID)
+	if nil != err {
+		return nil, err
+	}
+
+	missing, err := svc.repo.MissingContacts(ctx, data.IDs)
+	if nil != err {
+		return nil, err
+	}
+
+	if len(missing) > 0 {
+		ids := make([]string, 0, len(missing))
+		for _, id := range missing {
+			ids = append(ids, id.String())
+		}
+		return nil, ErrMemberNotExist.WithDetail("ids", strings.Join(ids, ","))
+	}
+
+	count, err := svc.repo.AddMembers(ctx, data.GroupID, data.IDs, svc.actor(ctx))
+	if nil != err {
+		return nil, err
+	}
+
+	return &model.MembersResult{Count: count}, nil
+}
+
+// RemoveMembers remove contacts from group, ids that are not members are ignored
+func (svc *Service) RemoveMembers(ctx context.Context, data *model.Members) (*model.MembersResult, error) {
+	_, err := svc.FetchByID(ctx, data.GroupID)
+	if nil != err {
+		return nil, err
+	}
+
+	count, err := svc.repo.RemoveMembers(ctx, data.GroupID, data.IDs)
+	if nil != err {
+		return nil, err
+	}
+
+	return &model.MembersResult{Count: count}, nil
+}
+
+// normalize trim name so groups differing only by surrounding spaces collide
+func normalize(data *model.Group) error {
+	name := strings.TrimSpace(*data.Name)
+	if name == "" {
+		return httperror.ErrValidation.WithDetail("name", "name is required")
+	}
+
+	data.Name = &name
+	return nil
+}
+
+// conflict turn duplicate name into its catalogue error
+func conflict(err error) error {
+	if repository.ErrDuplicateName == err {
+		return ErrGroupNameTaken
+	}
+	return err
+}
+
+// actor name recorded on created_by and updated_by, the authenticated principal
+// or the name of the process for background work
+func (svc *Service) actor(ctx context.Context) string {
+	return auth.Actor(ctx, svc.Actor)
+}
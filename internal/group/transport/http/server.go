@@ -0,0 +1,139 @@
+package http
+
+import (
+	"net/http"
+
+	"phonebook/internal/group"
+	"phonebook/internal/group/endpoint"
+	"phonebook/internal/group/model"
+	"phonebook/pkg/server"
+
+	kitendpoint "github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	kithttp "github.com/go-kit/kit/transport/http"
+)
+
+func List(svc *group.Service, logger log.Logger, opts []kithttp.ServerOption, middlewares ...kitendpoint.Middleware) http.Handler {
+	end := endpoint.List(svc)
+	var serverLogger *server.Logger
+	if nil != logger {
+		serverLogger = &server.Logger{
+			Logger:    logger,
+			Namespace: "group",
+			Subsystem: "list_group",
+			Action:    "GET",
+		}
+	}
+	return server.NewHTTPServer(end, server.HTTPOption{
+		Logger:      serverLogger,
+		Middlewares: middlewares,
+	}, opts...)
+}
+
+func Create(svc *group.Service, logger log.Logger, opts []kithttp.ServerOption, middlewares ...kitendpoint.Middleware) http.Handler {
+	end := endpoint.Create(svc)
+	var serverLogger *server.Logger
+	if nil != logger {
+		serverLogger = &server.Logger{
+			Logger:    logger,
+			Namespace: "group",
+			Subsystem: "add_new_group",
+			Action:    "POST",
+		}
+	}
+	return server.NewHTTPServer(end, server.HTTPOption{
+		DecodeModel: &model.Group{},
+		Logger:      serverLogger,
+		Middlewares: middlewares,
+	}, opts...)
+}
+
+func FetchByID(svc *group.Service, logger log.Logger, opts []kithttp.ServerOption, middlewares ...kitendpoint.Middleware) http.Handler {
+	end := endpoint.FetchByID(svc)
+	var serverLogger *server.Logger
+	if nil != logger {
+		serverLogger = &server.Logger{
+			Logger:    logger,
+			Namespace: "group",
+			Subsystem: "get_group",
+			Action:    "GET",
+		}
+	}
+	return server.NewHTTPServer(end, server.HTTPOption{
+		DecodeModel: &model.GetGroup{},
+		Logger:      serverLogger,
+		Middlewares: middlewares,
+	}, opts...)
+}
+
+func Update(svc *group.Service, logger log.Logger, opts []kithttp.ServerOption, middlewares ...kitendpoint.Middleware) http.Handler {
+	end := endpoint.Update(svc)
+	var serverLogger *server.Logger
+	if nil != logger {
+		serverLogger = &server.Logger{
+			Logger:    logger,
+			Namespace: "group",
+			Subsystem: "update_group",
+			Action:    "PUT",
+		}
+	}
+	return server.NewHTTPServer(end, server.HTTPOption{
+		DecodeModel: &model.Group{},
+		Logger:      serverLogger,
+		Middlewares: middlewares,
+	}, opts...)
+}
+
+func Remove(svc *group.Service, logger log.Logger, opts []kithttp.ServerOption, middlewares ...kitendpoint.Middleware) http.Handler {
+	end := endpoint.Remove(svc)
+	var serverLogger *server.Logger
+	if nil != logger {
+		serverLogger = &server.Logger{
+			Logger:    logger,
+			Namespace: "group",
+			Subsystem: "remove_group",
+			Action:    "DELETE",
+		}
+	}
+	return server.NewHTTPServer(end, server.HTTPOption{
+		DecodeModel: &model.GetGroup{},
+		Logger:      serverLogger,
+		Middlewares: middlewares,
+	}, opts...)
+}
+
+func AddMembers(svc *group.Service, logger log.Logger, opts []kithttp.ServerOption, middlewares ...kitendpoint.Middleware) http.Handler {
+	end := endpoint.AddMembers(svc)
+	var serverLogger *server.Logger
+	if nil != logger {
+		serverLogger = &server.Logger{
+			Logger:    logger,
+			Namespace: "group",
+			Subsystem: "add_group_members",
+			Action:    "POST",
+		}
+	}
+	return server.NewHTTPServer(end, server.HTTPOption{
+		DecodeModel: &model.Members{},
+		Logger:      serverLogger,
+		Middlewares: middlewares,
+	}, opts...)
+}
+
+func RemoveMembers(svc *group.Service, logger log.Logger, opts []kithttp.ServerOption, middlewares ...kitendpoint.Middleware) http.Handler {
+	end := endpoint.RemoveMembers(svc)
+	var serverLogger *server.Logger
+	if nil != logger {
+		serverLogger = &server.Logger{
+			Logger:    logger,
+			Namespace: "group",
+			Subsystem: "remove_group_members",
+			Action:    "DELETE",
+		}
+	}
+	return server.NewHTTPServer(end, server.HTTPOption{
+		DecodeModel: &model.Members{},
+		Logger:      serverLogger,
+		Middlewares: middlewares,
+	}, opts...)
+}
@@ -0,0 +1,158 @@
+package phonebook
+
+import (
+	// internal package
+	"phonebook/internal/phonebook/model"
+	"phonebook/pkg/httperror"
+	"phonebook/pkg/validator"
+)
+
+// normalizeContacts keep phone_number and address columns in sync with the primary
+// phone and address entries and convert every phone into E.164,
+// current is nil when the profile is being created
+func normalizeContacts(data *model.PhoneBook, current *model.PhoneBook) error {
+	details := make(map[string]string)
+
+	// legacy single value replace the number of the current primary entry
+	if nil == data.Phones && nil != data.PhoneNumber {
+		data.Phones = make([]*model.ContactPhone, 0)
+		if nil != current {
+			for _, phone := range current.Phones {
+				copied := *phone
+				data.Phones = append(data.Phones, &copied)
+			}
+		}
+
+		idx := primaryIndex(len(data.Phones), func(i int) bool { return data.Phones[i].Primary })
+		if idx < 0 {
+			data.Phones = append([]*model.ContactPhone{{Label: model.LabelMobile, Primary: true}}, data.Phones...)
+			idx = 0
+		}
+		data.Phones[idx].Number = *data.PhoneNumber
+	}
+
+	if nil == data.Addresses && nil != data.Address {
+		data.Addresses = make([]*model.ContactAddress, 0)
+		if nil != current {
+			for _, address := range current.Addresses {
+				copied := *address
+				data.Addresses = append(data.Addresses, &copied)
+			}
+		}
+
+		idx := primaryIndex(len(data.Addresses), func(i int) bool { return data.Addresses[i].Primary })
+		if idx < 0 {
+			data.Addresses = append([]*model.ContactAddress{{Label: model.LabelHome, Primary: true}}, data.Addresses...)
+			idx = 0
+		}
+		data.Addresses[idx].Address = *data.Address
+	}
+
+	if nil != data.Phones {
+		idx, ok := choosePrimary(len(data.Phones),
+			func(i int) bool { return data.Phones[i].Primary },
+			func(i int) { data.Phones[i].Primary = true },
+		)
+		for _, phone := range data.Phones {
+			if phone.Label == "" {
+				phone.Label = model.LabelMobile
+			}
+
+			// raw number is kept as typed, uniqueness is checked on the E.164 form
+			e164, err := validator.NormalizePhone(phone.Number, validator.PhoneRegion())
+			if nil != err {
+				details["phones"] = phone.Number + " is not a valid phone number"
+				continue
+			}
+			phone.NumberE164 = &e164
+		}
+		switch {
+		case !ok:
+			details["phones"] = "only one primary phone is allowed"
+		case idx < 0:
+			details["phones"] = "at least one phone is required"
+		default:
+			data.PhoneNumber = &data.Phones[idx].Number
+			data.PhoneNumberE164 = data.Phones[idx].NumberE164
+		}
+	} else if nil == current {
+		details["phones"] = "at least one phone is required"
+	}
+
+	if nil != data.Addresses {
+		idx, ok := choosePrimary(len(data.Addresses),
+			func(i int) bool { return data.Addresses[i].Primary },
+			func(i int) { data.Addresses[i].Primary = true },
+		)
+		empty := ""
+		switch {
+		case !ok:
+			details["addresses"] = "only one primary address is allowed"
+		case idx < 0:
+			data.Address = &empty
+		default:
+			data.Address = &data.Addresses[idx].Address
+		}
+		for _, address := range data.Addresses {
+			if address.Label == "" {
+				address.Label = model.LabelHome
+			}
+		}
+	} else if nil == current && nil == data.Address {
+		empty := ""
+		data.Address = &empty
+	}
+
+	if nil != data.Emails {
+		_, ok := choosePrimary(len(data.Emails),
+			func(i int) bool { return data.Emails[i].Primary },
+			func(i int) { data.Emails[i].Primary = true },
+		)
+		if !ok {
+			details["emails"] = "only one primary email is allowed"
+		}
+		for _, email := range data.Emails {
+			if email.Label == "" {
+				email.Label = model.LabelOther
+			}
+		}
+	}
+
+	if len(details) > 0 {
+		return httperror.ErrValidation.WithDetails(details)
+	}
+
+	return nil
+}
+
+// primaryIndex index of the first primary entry, -1 when there is none
+func primaryIndex(n int, primary func(i int) bool) int {
+	for i := 0; i < n; i++ {
+		if primary(i) {
+			return i
+		}
+	}
+	return -1
+}
+
+// choosePrimary return index of the primary entry, the first entry is promoted when
+// none is flagged and idx is -1 for an empty list. ok is false when several are flagged
+func choosePrimary(n int, primary func(i int) bool, promote func(i int)) (idx int, ok bool) {
+	idx = -1
+	for i := 0; i < n; i++ {
+		if !primary(i) {
+			continue
+		}
+		if idx >= 0 {
+			return idx, false
+		}
+		idx = i
+	}
+
+	if idx < 0 && n > 0 {
+		idx = 0
+		promote(idx)
+	}
+
+	return idx, true
+}
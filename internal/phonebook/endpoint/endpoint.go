@@ -3,36 +3,110 @@ package endpoint
 import (
 	// internal golang package
 	"context"
+	"database/sql"
 
 	// internal package
-	"phonebook/internal/global"
 	"phonebook/internal/phonebook"
 	"phonebook/internal/phonebook/model"
+	pkghttp "phonebook/pkg/http"
 	"phonebook/pkg/queryable"
 
 	// thirdparty package
 	"github.com/go-kit/kit/endpoint"
 )
 
+// endpoints passing these options only work in the database, an attempt rolled back on a
+// serialization failure leave nothing behind and is run again. Writes read the row they
+// change first, repeatable read turn a concurrent change of it into such a failure
+var (
+	read  = []queryable.TxOption{queryable.ReadOnly(), queryable.Retry(queryable.DefaultRetries, queryable.DefaultBackoff)}
+	write = []queryable.TxOption{queryable.Isolation(sql.LevelRepeatableRead), queryable.Retry(queryable.DefaultRetries, queryable.DefaultBackoff)}
+)
+
 // FetchData ...
 func FetchData(svc *phonebook.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
-		err = queryable.RunInTransaction(ctx, global.DB(), func(ctx context.Context) error {
+		err = queryable.RunInTransaction(ctx, svc.DB, func(ctx context.Context) error {
 			reqData := request.(*model.GetPhoneList)
 			response, err = svc.FetchData(ctx, reqData)
 			return err
+		}, read...)
+		return response, err
+	}
+}
+
+// Export ...
+func Export(svc *phonebook.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		err = queryable.RunInTransaction(ctx, svc.DB, func(ctx context.Context) error {
+			reqData := request.(*model.GetPhoneList)
+			response, err = svc.ExportData(ctx, reqData)
+			return err
 		})
 		return response, err
 	}
 }
 
+// Search ...
+func Search(svc *phonebook.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		err = queryable.RunInTransaction(ctx, svc.DB, func(ctx context.Context) error {
+			reqData := request.(*model.SearchPhoneBook)
+			response, err = svc.SearchData(ctx, reqData)
+			return err
+		}, read...)
+		return response, err
+	}
+}
+
+// Import ...
+// every batch is saved in its own transaction so it does not run inside RunInTransaction
+func Import(svc *phonebook.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		reqData := request.(*model.ImportPhoneBook)
+		return svc.ImportData(ctx, reqData)
+	}
+}
+
+// FetchByID ...
+func FetchByID(svc *phonebook.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		err = queryable.RunInTransaction(ctx, svc.DB, func(ctx context.Context) error {
+			reqData := request.(*model.GetPhoneBook)
+			result, err := svc.FetchByID(ctx, reqData.ID)
+			if nil != err {
+				return err
+			}
+
+			response = result
+			if pkghttp.MatchETag(reqData.IfNoneMatch, result.ETag(), true) {
+				response = pkghttp.NotModified{ETag: result.ETag()}
+			}
+			return nil
+		}, read...)
+		return response, err
+	}
+}
+
+// History ...
+func History(svc *phonebook.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		err = queryable.RunInTransaction(ctx, svc.DB, func(ctx context.Context) error {
+			reqData := request.(*model.GetHistory)
+			response, err = svc.HistoryData(ctx, reqData.ID)
+			return err
+		}, read...)
+		return response, err
+	}
+}
+
 // Add ...
 func Add(svc *phonebook.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
-		err = queryable.RunInTransaction(ctx, global.DB(), func(ctx context.Context) error {
+		err = queryable.RunInTransaction(ctx, svc.DB, func(ctx context.Context) error {
 			reqData := request.(*model.PhoneBook)
 			return svc.CreatePhoneAddress(ctx, reqData)
-		})
+		}, write...)
 
 		return nil, err
 	}
@@ -41,21 +115,69 @@ func Add(svc *phonebook.Service) endpoint.Endpoint {
 // Update ...
 func Update(svc *phonebook.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
-		err = queryable.RunInTransaction(ctx, global.DB(), func(ctx context.Context) error {
+		err = queryable.RunInTransaction(ctx, svc.DB, func(ctx context.Context) error {
 			reqData := request.(*model.PhoneBook)
-			return svc.UpdateData(ctx, reqData)
-		})
-		return nil, err
+			response, err = svc.UpdateData(ctx, reqData)
+			return err
+		}, write...)
+		return response, err
+	}
+}
+
+// Patch ...
+func Patch(svc *phonebook.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		err = queryable.RunInTransaction(ctx, svc.DB, func(ctx context.Context) error {
+			reqData := request.(*model.PatchPhoneBook)
+			response, err = svc.PatchData(ctx, reqData)
+			return err
+		}, write...)
+		return response, err
 	}
 }
 
 // Remove ...
 func Remove(svc *phonebook.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
-		err = queryable.RunInTransaction(ctx, global.DB(), func(ctx context.Context) error {
+		err = queryable.RunInTransaction(ctx, svc.DB, func(ctx context.Context) error {
 			reqData := request.(*model.PhoneBook)
 			return svc.RemoveData(ctx, reqData)
-		})
+		}, write...)
+		return nil, err
+	}
+}
+
+// ListDeleted ...
+func ListDeleted(svc *phonebook.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		err = queryable.RunInTransaction(ctx, svc.DB, func(ctx context.Context) error {
+			reqData := request.(*model.GetPhoneList)
+			response, err = svc.ListDeleted(ctx, reqData)
+			return err
+		}, read...)
+		return response, err
+	}
+}
+
+// Restore ...
+func Restore(svc *phonebook.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		err = queryable.RunInTransaction(ctx, svc.DB, func(ctx context.Context) error {
+			reqData := request.(*model.GetPhoneBook)
+			response, err = svc.RestoreData(ctx, reqData.ID)
+			return err
+		}, write...)
+		return response, err
+	}
+}
+
+// Purge ...
+func Purge(svc *phonebook.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		err = queryable.RunInTransaction(ctx, svc.DB, func(ctx context.Context) error {
+			reqData := request.(*model.GetPhoneBook)
+			return svc.PurgeData(ctx, reqData.ID)
+		}, write...)
 		return nil, err
 	}
 }
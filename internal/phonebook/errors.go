@@ -0,0 +1,19 @@
+package phonebook
+
+import (
+	// internal package
+	"phonebook/pkg/httperror"
+)
+
+// error catalogue of phonebook domain
+var (
+	ErrProfileNotExist        = httperror.New(httperror.NotFound, "profile_not_exist", "profile does not exist")
+	ErrPhoneAlreadyRegistered = httperror.New(httperror.Conflict, "phone_already_registered", "phone number is already registered")
+	ErrInvalidCursor          = httperror.New(httperror.Invalid, "invalid_cursor", "cursor is not valid")
+	ErrUnsupportedImport      = httperror.New(httperror.UnsupportedMediaType, "unsupported_import_format", "import only accept text/csv and text/vcard")
+	ErrInvalidMapping         = httperror.New(httperror.Invalid, "invalid_mapping", "column mapping is not valid")
+	ErrDuplicateInFile        = httperror.New(httperror.Conflict, "duplicate_in_file", "phone number is repeated inside the file")
+	ErrProfileNotDeleted      = httperror.New(httperror.Conflict, "profile_not_deleted", "profile is not deleted")
+	ErrVersionMismatch        = httperror.New(httperror.PreconditionFailed, "version_mismatch", "profile has been modified by another request")
+	ErrExportTooLarge         = httperror.New(httperror.Invalid, "export_too_large", "too many profiles to export at once, use POST /v1/exports")
+)
@@ -0,0 +1,511 @@
+package phonebook
+
+import (
+	// internal golang package
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+
+	// internal package
+	"phonebook/config"
+	"phonebook/internal/phonebook/model"
+	"phonebook/internal/phonebook/repository"
+	"phonebook/pkg/httperror"
+	"phonebook/pkg/queryable"
+	"phonebook/pkg/validator"
+	"phonebook/pkg/vcard"
+
+	// thirdparty package
+	"github.com/google/uuid"
+)
+
+// importFields field that can be mapped to csv column, in the default column order
+var importFields = []string{"fullname", "phone_number", "address", "email"}
+
+// importAliases header names recognized when the file has no explicit mapping
+var importAliases = map[string]string{
+	"name":         "fullname",
+	"fullname":     "fullname",
+	"full name":    "fullname",
+	"phone":        "phone_number",
+	"phone_number": "phone_number",
+	"phone number": "phone_number",
+	"mobile":       "phone_number",
+	"address":      "address",
+	"email":        "email",
+	"e-mail":       "email",
+}
+
+// importColumn mapping target, either a header name or a column index
+type importColumn struct {
+	header string
+	index  int
+}
+
+// importRecord parsed record waiting for its batch to be saved
+type importRecord struct {
+	row  *model.ImportRow
+	data *model.PhoneBook
+}
+
+// importBatch valid records waiting to be saved together
+type importBatch struct {
+	svc    *Service
+	size   int
+	seen   map[string]int
+	items  []*importRecord
+	result *model.ImportResult
+}
+
+// ImportData import contacts from csv or vCard body. The body is read record by record,
+// every record is validated on its own and valid records are saved per batch
+func (svc *Service) ImportData(ctx context.Context, params *model.ImportPhoneBook) (*model.ImportResult, error) {
+	mediaType, _, err := mime.ParseMediaType(params.ContentType)
+	if nil != err {
+		return nil, ErrUnsupportedImport
+	}
+
+	var read func(context.Context, *model.ImportPhoneBook, *importBatch) error
+	switch mediaType {
+	case "text/csv", "application/csv":
+		read = svc.importCSV
+	case vcard.MediaType, "text/x-vcard":
+		read = svc.importVCard
+	default:
+		return nil, ErrUnsupportedImport
+	}
+
+	cfg, err := config.Get()
+	if nil != err {
+		return nil, err
+	}
+
+	batch := &importBatch{
+		svc:   svc,
+		size:  cfg.ImportBatchSize,
+		seen:  make(map[string]int),
+		items: make([]*importRecord, 0, cfg.ImportBatchSize),
+		result: &model.ImportResult{
+			DryRun: params.DryRun,
+			Rows:   make([]*model.ImportRow, 0),
+		},
+	}
+
+	if err = read(ctx, params, batch); nil != err {
+		return nil, err
+	}
+
+	if err = batch.flush(ctx); nil != err {
+		return nil, err
+	}
+
+	result := batch.result
+	sort.Slice(result.Rows, func(i, j int) bool {
+		return result.Rows[i].Record < result.Rows[j].Record
+	})
+
+	return result, nil
+}
+
+// importCSV read csv records, the first record may be a header
+func (svc *Service) importCSV(ctx context.Context, params *model.ImportPhoneBook, batch *importBatch) error {
+	mapping, err := parseMapping(params.Mapping)
+	if nil != err {
+		return err
+	}
+
+	reader := csv.NewReader(params.Body)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	if params.Delimiter != "" {
+		reader.Comma = []rune(params.Delimiter)[0]
+	}
+
+	first, err := reader.Read()
+	if io.EOF == err {
+		return nil
+	}
+	if nil != err {
+		return ErrInvalidMapping.Wrap(err)
+	}
+
+	header := params.Header == "true" || (params.Header != "false" && isHeader(first, mapping))
+
+	var columns map[string]int
+	if header {
+		columns, err = resolveColumns(mapping, first)
+	} else {
+		columns, err = resolveColumns(mapping, nil)
+	}
+	if nil != err {
+		return err
+	}
+
+	actor := svc.actor(ctx)
+	for record := 1; ; record++ {
+		var fields []string
+		if record == 1 && !header {
+			fields = first
+		} else if record > 1 {
+			fields, err = reader.Read()
+			if io.EOF == err {
+				return nil
+			}
+			if perr, ok := err.(*csv.ParseError); ok {
+				batch.result.Add(&model.ImportRow{Record: record, Status: model.ImportFailed, Reason: perr.Err.Error()})
+				continue
+			}
+			if nil != err {
+				return err
+			}
+		}
+
+		if nil == fields {
+			continue
+		}
+
+		data, err := buildImportRecord(fields, columns, actor)
+		if err = batch.add(ctx, &model.ImportRow{Record: record}, data, err); nil != err {
+			return err
+		}
+	}
+}
+
+// importVCard read cards of a multi card vCard file, every card is one record
+func (svc *Service) importVCard(ctx context.Context, params *model.ImportPhoneBook, batch *importBatch) error {
+	decoder := vcard.NewDecoder(params.Body)
+
+	actor := svc.actor(ctx)
+	for record := 1; ; record++ {
+		card, err := decoder.Decode()
+		if io.EOF == err {
+			return nil
+		}
+		if vcard.ErrMalformed == err {
+			// the remaining content can not be split into cards anymore
+			batch.result.Add(&model.ImportRow{Record: record, Status: model.ImportFailed, Reason: err.Error()})
+			return nil
+		}
+		if nil != err {
+			return err
+		}
+
+		data, err := prepareImportRecord(model.PhoneBookFromCard(card), actor)
+		if err = batch.add(ctx, &model.ImportRow{Record: record}, data, err); nil != err {
+			return err
+		}
+	}
+}
+
+// add queue valid record, or report it when err is not nil. The batch is saved once it is full
+func (b *importBatch) add(ctx context.Context, row *model.ImportRow, data *model.PhoneBook, err error) error {
+	if nil == err {
+		// phones of one file must be unique as well
+		for _, phone := range data.Phones {
+			if previous, ok := b.seen[*phone.NumberE164]; ok {
+				err = ErrDuplicateInFile.WithDetail("record", strconv.Itoa(previous))
+				row.Status = model.ImportSkipped
+				break
+			}
+		}
+		if nil == err {
+			for _, phone := range data.Phones {
+				b.seen[*phone.NumberE164] = row.Record
+			}
+		}
+	}
+
+	if nil != err {
+		fillImportError(row, err)
+		b.result.Add(row)
+		return nil
+	}
+
+	b.items = append(b.items, &importRecord{row: row, data: data})
+	if len(b.items) >= b.size {
+		return b.flush(ctx)
+	}
+
+	return nil
+}
+
+// flush skip records whose phone is already registered and save the rest in one
+// transaction, when it fails every record is saved on its own to report the failing ones
+func (b *importBatch) flush(ctx context.Context) error {
+	if len(b.items) == 0 {
+		return nil
+	}
+	svc, result := b.svc, b.result
+
+	numbers := make([]string, 0, len(b.items))
+	for _, item := range b.items {
+		for _, phone := range item.data.Phones {
+			numbers = append(numbers, *phone.NumberE164)
+		}
+	}
+
+	// import does not run inside the transaction of its endpoint, the lookup needs
+	// one of its own so it sees the contacts of the tenant
+	var existing []*model.PhoneBook
+	err := queryable.RunInTransaction(ctx, svc.DB, func(ctx context.Context) (err error) {
+		existing, err = svc.repo.ListPhoneBook(ctx, &model.GetPhoneList{
+			PhonesE164: numbers,
+		})
+		return err
+	})
+	if nil != err {
+		return err
+	}
+
+	owners := make(map[string]uuid.UUID)
+	for _, contact := range existing {
+		for _, phone := range contact.Phones {
+			if nil != phone.NumberE164 {
+				owners[*phone.NumberE164] = contact.ID
+			}
+		}
+	}
+
+	pending := make([]*importRecord, 0, len(b.items))
+	for _, item := range b.items {
+		owned := false
+		for _, phone := range item.data.Phones {
+			if owner, ok := owners[*phone.NumberE164]; ok && !owned {
+				owned = true
+				item.row.Status = model.ImportSkipped
+				fillImportError(item.row, ErrPhoneAlreadyRegistered.WithDetail("id", owner.String()))
+				result.Add(item.row)
+			}
+		}
+		if !owned {
+			pending = append(pending, item)
+		}
+	}
+	b.items = b.items[:0]
+
+	if !result.DryRun && len(pending) > 0 {
+		data := make([]*model.PhoneBook, 0, len(pending))
+		for _, item := range pending {
+			data = append(data, item.data)
+		}
+
+		if err = svc.repo.AddingPeople(ctx, data); nil != err {
+			for _, item := range pending {
+				err = svc.repo.AddingPerson(ctx, item.data)
+				var dup *repository.DuplicatePhoneError
+				if errors.As(err, &dup) {
+					item.row.Status = model.ImportSkipped
+				} else if nil != err {
+					svc.Logger.Log("action", "import", "record", item.row.Record, "err", err)
+				}
+				if nil != err {
+					fillImportError(item.row, conflict(err))
+				}
+			}
+		}
+	}
+
+	for _, item := range pending {
+		if item.row.Status == "" {
+			item.row.Status = model.ImportCreated
+			if !result.DryRun {
+				id := item.data.ID
+				item.row.ID = &id
+			}
+		}
+		result.Add(item.row)
+	}
+
+	return nil
+}
+
+// buildImportRecord convert csv fields into profile
+func buildImportRecord(fields []string, columns map[string]int, actor string) (*model.PhoneBook, error) {
+	value := func(field string) *string {
+		idx, ok := columns[field]
+		if !ok || idx >= len(fields) {
+			return nil
+		}
+		v := strings.TrimSpace(fields[idx])
+		if v == "" {
+			return nil
+		}
+		return &v
+	}
+
+	data := &model.PhoneBook{
+		Fullname:    value("fullname"),
+		PhoneNumber: value("phone_number"),
+		Address:     value("address"),
+	}
+
+	if email := value("email"); nil != email {
+		data.Emails = []*model.ContactEmail{{Email: *email}}
+	}
+
+	if nil == data.PhoneNumber {
+		return nil, httperror.ErrValidation.WithDetail("phone_number", "phone_number is required")
+	}
+
+	return prepareImportRecord(data, actor)
+}
+
+// prepareImportRecord assign id and creator of imported profile, validated and normalized as CreatePhoneAddress does
+func prepareImportRecord(data *model.PhoneBook, actor string) (*model.PhoneBook, error) {
+	if nil == data.Fullname {
+		return nil, httperror.ErrValidation.WithDetail("fullname", "fullname is required")
+	}
+
+	id, err := uuid.NewRandom()
+	if nil != err {
+		return nil, err
+	}
+	data.ID = id
+	data.CreatedBy = &actor
+
+	err = validator.DefaultValidator()(data)
+	if nil != err {
+		if details, ok := validator.Translate(err); ok {
+			return nil, httperror.ErrValidation.WithDetails(details)
+		}
+		return nil, err
+	}
+
+	err = normalizeContacts(data, nil)
+	if nil != err {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// fillImportError mark row as failed, unless it is already skipped, with reason from err
+func fillImportError(row *model.ImportRow, err error) {
+	if row.Status == "" {
+		row.Status = model.ImportFailed
+	}
+
+	var e *httperror.Error
+	if errors.As(err, &e) {
+		row.Reason = e.Code()
+		if len(e.Details()) > 0 {
+			row.Details = e.Details()
+		}
+		return
+	}
+
+	row.Reason = httperror.ErrInternal.Code()
+}
+
+// parseMapping parse "field:column" pairs separated by comma, column is a header name or zero based index
+func parseMapping(mapping string) (map[string]importColumn, error) {
+	result := make(map[string]importColumn)
+	if strings.TrimSpace(mapping) == "" {
+		return result, nil
+	}
+
+	details := make(map[string]string)
+	for _, pair := range strings.Split(mapping, ",") {
+		parts := strings.SplitN(pair, ":", 2)
+		field := strings.TrimSpace(parts[0])
+		if len(parts) != 2 || strings.TrimSpace(parts[1]) == "" {
+			details[field] = "column of " + field + " is required"
+			continue
+		}
+
+		known := false
+		for _, f := range importFields {
+			known = known || f == field
+		}
+		if !known {
+			details[field] = field + " is not an importable field"
+			continue
+		}
+
+		column := strings.TrimSpace(parts[1])
+		if idx, err := strconv.Atoi(column); nil == err && idx >= 0 {
+			result[field] = importColumn{index: idx}
+		} else {
+			result[field] = importColumn{header: column, index: -1}
+		}
+	}
+
+	if len(details) > 0 {
+		return nil, ErrInvalidMapping.WithDetails(details)
+	}
+
+	return result, nil
+}
+
+// isHeader detect whether first record is a header, it is when one of its cells
+// is a mapped header name or a well known field name
+func isHeader(first []string, mapping map[string]importColumn) bool {
+	for _, cell := range first {
+		cell = strings.ToLower(strings.TrimSpace(cell))
+		if _, ok := importAliases[cell]; ok {
+			return true
+		}
+		for _, column := range mapping {
+			if column.header != "" && strings.ToLower(column.header) == cell {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// resolveColumns turn mapping into column index of every field, header is nil for
+// files without header where unmapped fields follow the default column order
+func resolveColumns(mapping map[string]importColumn, header []string) (map[string]int, error) {
+	columns := make(map[string]int)
+	details := make(map[string]string)
+
+	for field, column := range mapping {
+		if column.index >= 0 {
+			columns[field] = column.index
+			continue
+		}
+
+		found := false
+		for i, cell := range header {
+			if strings.EqualFold(strings.TrimSpace(cell), column.header) {
+				columns[field] = i
+				found = true
+				break
+			}
+		}
+		if !found {
+			details[field] = "column " + column.header + " is not found in header"
+		}
+	}
+
+	if len(details) > 0 {
+		return nil, ErrInvalidMapping.WithDetails(details)
+	}
+
+	if len(mapping) > 0 {
+		return columns, nil
+	}
+
+	if nil == header {
+		for i, field := range importFields {
+			columns[field] = i
+		}
+		return columns, nil
+	}
+
+	for i, cell := range header {
+		if field, ok := importAliases[strings.ToLower(strings.TrimSpace(cell))]; ok {
+			if _, mapped := columns[field]; !mapped {
+				columns[field] = i
+			}
+		}
+	}
+
+	return columns, nil
+}
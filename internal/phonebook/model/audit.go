@@ -0,0 +1,66 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// action recorded in audit trail
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditRestore = "restore"
+	AuditPurge   = "purge"
+)
+
+// RawJSON jsonb column returned as is
+type RawJSON []byte
+
+// Scan copy jsonb value, the buffer of the driver is reused by the next row
+func (r *RawJSON) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		*r = append((*r)[:0], v...)
+	case string:
+		*r = RawJSON(v)
+	case nil:
+		*r = nil
+	default:
+		return errors.New("unsupported json type")
+	}
+	return nil
+}
+
+// MarshalJSON ...
+func (r RawJSON) MarshalJSON() ([]byte, error) {
+	if len(r) == 0 {
+		return []byte("null"), nil
+	}
+	return r, nil
+}
+
+// AuditEntry one mutation of profile with the profile before and after it
+type AuditEntry struct {
+	ID             int64          `db:"id" json:"id"`
+	PhoneBookID    uuid.UUID      `db:"phone_book_id" json:"phone_book_id"`
+	Action         string         `db:"action" json:"action"`
+	Actor          *string        `db:"actor" json:"actor"`
+	ChangedFields  pq.StringArray `db:"changed_fields" json:"changed_fields"`
+	Before         RawJSON        `db:"before" json:"before"`
+	After          RawJSON        `db:"after" json:"after"`
+	CreatedDateUTC *time.Time     `db:"created_date_utc" json:"created_date_utc"`
+}
+
+// GetHistory ...
+type GetHistory struct {
+	ID uuid.UUID `json:"id" httpurl:"id" validate:"required"`
+}
+
+// History audit trail of profile, oldest first
+type History struct {
+	Items []*AuditEntry `json:"items"`
+}
@@ -0,0 +1,41 @@
+package model
+
+import (
+	"github.com/google/uuid"
+)
+
+// label of phone, email and address entries
+const (
+	LabelMobile = "mobile"
+	LabelWork   = "work"
+	LabelHome   = "home"
+	LabelOther  = "other"
+)
+
+// ContactPhone one phone number of a contact
+type ContactPhone struct {
+	ID          uuid.UUID `db:"id" json:"id"`
+	PhoneBookID uuid.UUID `db:"phone_book_id" json:"-"`
+	Label       string    `db:"label" json:"label" validate:"omitempty,oneof=mobile work home other"`
+	Number      string    `db:"number" json:"number" validate:"required,phone"`
+	NumberE164  *string   `db:"number_e164" json:"number_e164"`
+	Primary     bool      `db:"is_primary" json:"primary"`
+}
+
+// ContactEmail one email of a contact
+type ContactEmail struct {
+	ID          uuid.UUID `db:"id" json:"id"`
+	PhoneBookID uuid.UUID `db:"phone_book_id" json:"-"`
+	Label       string    `db:"label" json:"label" validate:"omitempty,oneof=work home other"`
+	Email       string    `db:"email" json:"email" validate:"required,email"`
+	Primary     bool      `db:"is_primary" json:"primary"`
+}
+
+// ContactAddress one postal address of a contact
+type ContactAddress struct {
+	ID          uuid.UUID `db:"id" json:"id"`
+	PhoneBookID uuid.UUID `db:"phone_book_id" json:"-"`
+	Label       string    `db:"label" json:"label" validate:"omitempty,oneof=work home other"`
+	Address     string    `db:"address" json:"address" validate:"required"`
+	Primary     bool      `db:"is_primary" json:"primary"`
+}
@@ -0,0 +1,78 @@
+package model
+
+import (
+	"strconv"
+	"time"
+)
+
+// CSVHeader columns of csv list responses and exports, named like the import fields so the file can be imported back
+var CSVHeader = []string{
+	"id", "fullname", "phone_number", "phone_number_e164", "address", "email",
+	"created_date_utc", "updated_date_utc", "version",
+}
+
+// MarshalCSV ...
+func (p *PhoneBookPage) MarshalCSV() ([][]string, error) {
+	return marshalCSV(p.Items), nil
+}
+
+// MarshalCSV ...
+func (e *PhoneBookExport) MarshalCSV() ([][]string, error) {
+	return marshalCSV(e.Items), nil
+}
+
+// MarshalCSV ...
+func (s *SearchResponse) MarshalCSV() ([][]string, error) {
+	items := make([]*PhoneBook, 0, len(s.Items))
+	for _, item := range s.Items {
+		items = append(items, item.PhoneBook)
+	}
+	return marshalCSV(items), nil
+}
+
+func marshalCSV(items []*PhoneBook) [][]string {
+	records := make([][]string, 0, len(items)+1)
+	records = append(records, CSVHeader)
+
+	for _, item := range items {
+		records = append(records, item.CSVRecord())
+	}
+
+	return records
+}
+
+// CSVRecord profile as csv record in the order of CSVHeader
+func (p *PhoneBook) CSVRecord() []string {
+	email := ""
+	for _, e := range p.Emails {
+		if e.Primary {
+			email = e.Email
+		}
+	}
+
+	return []string{
+		p.ID.String(),
+		csvString(p.Fullname),
+		csvString(p.PhoneNumber),
+		csvString(p.PhoneNumberE164),
+		csvString(p.Address),
+		email,
+		csvTime(p.CreatedDateUTC),
+		csvTime(p.UpdatedDateUTC),
+		strconv.Itoa(p.Version),
+	}
+}
+
+func csvString(v *string) string {
+	if nil == v {
+		return ""
+	}
+	return *v
+}
+
+func csvTime(v *time.Time) string {
+	if nil == v {
+		return ""
+	}
+	return v.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,58 @@
+package model
+
+import (
+	"io"
+
+	"github.com/google/uuid"
+)
+
+// status of imported row
+const (
+	ImportCreated = "created"
+	ImportSkipped = "skipped"
+	ImportFailed  = "failed"
+)
+
+// ImportPhoneBook bulk import of contacts from csv body.
+// Mapping pairs field with header name or zero based column index,
+// e.g. "fullname:Name,phone_number:2", fields are fullname, phone_number, address and email
+type ImportPhoneBook struct {
+	ContentType string `json:"content_type" httpheader:"Content-Type"`
+	Mapping     string `json:"mapping" httpquery:"mapping"`
+	Header      string `json:"header" httpquery:"header" validate:"omitempty,oneof=auto true false"`
+	Delimiter   string `json:"delimiter" httpquery:"delimiter" validate:"omitempty,len=1"`
+	DryRun      bool   `json:"dry_run" httpquery:"dry_run"`
+
+	Body io.Reader `json:"-"`
+}
+
+// ImportRow outcome of one csv record or vCard card, record is counted from 1 and include the header
+type ImportRow struct {
+	Record  int               `json:"record"`
+	Status  string            `json:"status"`
+	ID      *uuid.UUID        `json:"id,omitempty"`
+	Reason  string            `json:"reason,omitempty"`
+	Details map[string]string `json:"details,omitempty"`
+}
+
+// ImportResult report of bulk import, on dry run created rows are only validated
+type ImportResult struct {
+	DryRun  bool         `json:"dry_run"`
+	Created int          `json:"created"`
+	Skipped int          `json:"skipped"`
+	Failed  int          `json:"failed"`
+	Rows    []*ImportRow `json:"rows"`
+}
+
+// Add record outcome of row
+func (r *ImportResult) Add(row *ImportRow) {
+	switch row.Status {
+	case ImportCreated:
+		r.Created++
+	case ImportSkipped:
+		r.Skipped++
+	default:
+		r.Failed++
+	}
+	r.Rows = append(r.Rows, row)
+}
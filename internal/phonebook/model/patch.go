@@ -0,0 +1,120 @@
+package model
+
+import (
+	"encoding/json"
+
+	"github.com/google/uuid"
+)
+
+// PatchString one member of JSON Merge Patch, Present is false when the key
+// is absent from the document and Value is nil when it is explicitly null
+type PatchString struct {
+	Present bool
+	Value   *string
+}
+
+// PatchPhoneBook JSON Merge Patch (RFC 7396) of phone book
+type PatchPhoneBook struct {
+	ID          uuid.UUID `httpurl:"id" validate:"required"`
+	Fullname    PatchString
+	PhoneNumber PatchString
+	Address     PatchString
+	IfMatch     string `httpheader:"If-Match"`
+
+	// arrays are replaced as a whole, nil when absent and empty when null
+	Phones    []*ContactPhone   `validate:"omitempty,dive"`
+	Emails    []*ContactEmail   `validate:"omitempty,dive"`
+	Addresses []*ContactAddress `validate:"omitempty,dive"`
+}
+
+func (p *PatchPhoneBook) fields() map[string]*PatchString {
+	return map[string]*PatchString{
+		"fullname":     &p.Fullname,
+		"phone_number": &p.PhoneNumber,
+		"address":      &p.Address,
+	}
+}
+
+// Empty report whether patch does not carry any member
+func (p *PatchPhoneBook) Empty() bool {
+	for _, field := range p.fields() {
+		if field.Present {
+			return false
+		}
+	}
+	return nil == p.Phones && nil == p.Emails && nil == p.Addresses
+}
+
+// Nulls name of members explicitly set to null
+func (p *PatchPhoneBook) Nulls() []string {
+	nulls := make([]string, 0)
+	for name, field := range p.fields() {
+		if field.Present && nil == field.Value {
+			nulls = append(nulls, name)
+		}
+	}
+	return nulls
+}
+
+// UnmarshalJSON decode merge patch document, unknown and read-only members are ignored
+func (p *PatchPhoneBook) UnmarshalJSON(data []byte) error {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); nil != err {
+		return err
+	}
+
+	for name, field := range p.fields() {
+		raw, ok := doc[name]
+		if !ok {
+			continue
+		}
+
+		field.Present = true
+		field.Value = nil
+		if err := json.Unmarshal(raw, &field.Value); nil != err {
+			return err
+		}
+	}
+
+	if err := unmarshalArray(doc, "phones", &p.Phones); nil != err {
+		return err
+	}
+	if err := unmarshalArray(doc, "emails", &p.Emails); nil != err {
+		return err
+	}
+	return unmarshalArray(doc, "addresses", &p.Addresses)
+}
+
+// unmarshalArray decode member name into a pointer to slice, null becomes an empty slice
+func unmarshalArray(doc map[string]json.RawMessage, name string, v interface{}) error {
+	raw, ok := doc[name]
+	if !ok {
+		return nil
+	}
+
+	if string(raw) == "null" {
+		raw = []byte("[]")
+	}
+
+	return json.Unmarshal(raw, v)
+}
+
+// MarshalJSON encode back into merge patch document
+func (p PatchPhoneBook) MarshalJSON() ([]byte, error) {
+	doc := make(map[string]interface{})
+	for name, field := range p.fields() {
+		if field.Present {
+			doc[name] = field.Value
+		}
+	}
+	if nil != p.Phones {
+		doc["phones"] = p.Phones
+	}
+	if nil != p.Emails {
+		doc["emails"] = p.Emails
+	}
+	if nil != p.Addresses {
+		doc["addresses"] = p.Addresses
+	}
+	return json.Marshal(doc)
+}
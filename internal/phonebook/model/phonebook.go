@@ -1,6 +1,8 @@
 package model
 
 import (
+	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
@@ -8,24 +10,74 @@ import (
 
 // PhoneBook ...
 type PhoneBook struct {
-	ID             uuid.UUID  `db:"id" json:"id" validate:"required"`
-	Fullname       *string    `db:"fullname" json:"fullname"`
-	PhoneNumber    *string    `db:"phone_number" json:"phone_number"`
-	Address        *string    `db:"address" json:"address"`
-	CreatedDateUTC *time.Time `db:"created_date_utc" json:"created_date_utc"`
-	CreatedBy      *string    `db:"created_by" json:"created_by"`
-	UpdatedDateUTC *time.Time `db:"updated_date_by" json:"updated_date_utc"`
-	UpdatedBy      *string    `db:"updated_by" json:"updated_by"`
-	DeletedDateUTC *time.Time `db:"deleted_date_utc" json:"deleted_date_utc"`
-	DeletedBy      *string    `db:"deleted_by" json:"deleted_by"`
+	ID              uuid.UUID  `db:"id" json:"id" httpurl:"id"`
+	Fullname        *string    `db:"fullname" json:"fullname"`
+	PhoneNumber     *string    `db:"phone_number" json:"phone_number" validate:"omitempty,phone"`
+	PhoneNumberE164 *string    `db:"phone_number_e164" json:"phone_number_e164"`
+	Address         *string    `db:"address" json:"address"`
+	CreatedDateUTC  *time.Time `db:"created_date_utc" json:"created_date_utc"`
+	CreatedBy       *string    `db:"created_by" json:"created_by"`
+	UpdatedDateUTC  *time.Time `db:"updated_date_utc" json:"updated_date_utc"`
+	UpdatedBy       *string    `db:"updated_by" json:"updated_by"`
+	DeletedDateUTC  *time.Time `db:"deleted_date_utc" json:"deleted_date_utc"`
+	DeletedBy       *string    `db:"deleted_by" json:"deleted_by"`
+	Version         int        `db:"version" json:"version"`
+	TenantID        *string    `db:"tenant_id" json:"-"`
+
+	// nil leaves the stored entries untouched on update
+	Phones    []*ContactPhone   `db:"-" json:"phones" validate:"omitempty,dive"`
+	Emails    []*ContactEmail   `db:"-" json:"emails" validate:"omitempty,dive"`
+	Addresses []*ContactAddress `db:"-" json:"addresses" validate:"omitempty,dive"`
+
+	IfMatch string `db:"-" json:"-" httpheader:"If-Match"`
+}
+
+// ETag entity tag of profile, changed by every update
+func (p *PhoneBook) ETag() string {
+	return `"` + strconv.Itoa(p.Version) + `"`
+}
+
+// Headers ...
+func (p *PhoneBook) Headers() http.Header {
+	return http.Header{
+		"Etag": []string{p.ETag()},
+	}
+}
+
+// GetPhoneBook ...
+type GetPhoneBook struct {
+	ID          uuid.UUID `json:"id" httpurl:"id" validate:"required"`
+	IfNoneMatch string    `json:"-" httpheader:"If-None-Match"`
 }
 
 // GetPhoneList ...
 type GetPhoneList struct {
-	ID          uuid.UUID `json:"id" httpquery:"id"`
-	Fullname    *string   `json:"fullname" httpquery:"fullname"`
-	PhoneNumber *string   `json:"phone_number" httpquery:"phone_number"`
-	Address     *string   `json:"address" httpquery:"address"`
-	OffsetID    uuid.UUID `json:"offset_id" httpquery:"offset_id"`
-	Limit       *int      `json:"limit" httpquery:"limit"`
+	ID          uuid.UUID   `json:"id" httpquery:"id"`
+	Fullname    *string     `json:"fullname" httpquery:"fullname"`
+	PhoneNumber *string     `json:"phone_number" httpquery:"phone_number"`
+	Address     *string     `json:"address" httpquery:"address"`
+	Groups      []uuid.UUID `json:"group" httpquery:"group"`
+	Cursor      *string     `json:"cursor" httpquery:"cursor"`
+	PhonesE164  []string    `json:"-"`
+	OnlyDeleted bool        `json:"-"`
+	Limit       *int        `json:"limit" httpquery:"limit"`
+	WithTotal   bool        `json:"with_total" httpquery:"with_total"`
+	Position    *Position   `json:"-"`
+}
+
+// Position decoded cursor, rows are ordered by created_date_utc and id
+// so rows sharing the same created_date_utc are not skipped
+type Position struct {
+	CreatedDateUTC time.Time `json:"t"`
+	ID             uuid.UUID `json:"i"`
+	Backward       bool      `json:"b,omitempty"`
+}
+
+// PhoneBookPage one page of phone book list
+type PhoneBookPage struct {
+	Items      []*PhoneBook `json:"items"`
+	NextCursor *string      `json:"next_cursor"`
+	PrevCursor *string      `json:"prev_cursor"`
+	HasMore    bool         `json:"has_more"`
+	Total      *int         `json:"total,omitempty"`
 }
@@ -0,0 +1,24 @@
+package model
+
+// SearchPhoneBook typo tolerant search across name, phone and address
+type SearchPhoneBook struct {
+	Query string `json:"q" httpquery:"q" validate:"required"`
+	Limit *int   `json:"limit" httpquery:"limit"`
+
+	// filled by service from Query
+	TSQuery     string `json:"-"`
+	PhonePrefix string `json:"-"`
+}
+
+// SearchResult one ranked contact, highlights are html escaped text wrapping matched words with <mark></mark>
+type SearchResult struct {
+	*PhoneBook
+	Rank              float64 `db:"rank" json:"rank"`
+	FullnameHighlight *string `db:"fullname_highlight" json:"fullname_highlight"`
+	AddressHighlight  *string `db:"address_highlight" json:"address_highlight"`
+}
+
+// SearchResponse ...
+type SearchResponse struct {
+	Items []*SearchResult `json:"items"`
+}
@@ -0,0 +1,171 @@
+package model
+
+import (
+	"bytes"
+	"net/http"
+
+	"phonebook/pkg/vcard"
+)
+
+// vcardTypes TYPE parameter of every label, mobile phones are "cell" in vCard
+var vcardTypes = map[string]string{
+	LabelMobile: "cell",
+	LabelWork:   "work",
+	LabelHome:   "home",
+}
+
+// PhoneBookExport contacts exported as one multi card vCard file
+type PhoneBookExport struct {
+	Items []*PhoneBook `json:"items"`
+}
+
+// MarshalVCard ...
+func (e *PhoneBookExport) MarshalVCard(version string) ([]byte, error) {
+	return marshalVCard(version, e.Items...)
+}
+
+// Headers ...
+func (e *PhoneBookExport) Headers() http.Header {
+	return http.Header{
+		"Content-Disposition": []string{`attachment; filename="phonebook.vcf"`},
+	}
+}
+
+// MarshalVCard ...
+func (p *PhoneBookPage) MarshalVCard(version string) ([]byte, error) {
+	return marshalVCard(version, p.Items...)
+}
+
+// MarshalVCard ...
+func (p *PhoneBook) MarshalVCard(version string) ([]byte, error) {
+	return marshalVCard(version, p)
+}
+
+func marshalVCard(version string, items ...*PhoneBook) ([]byte, error) {
+	var buffer bytes.Buffer
+	encoder := vcard.NewEncoder(&buffer, version)
+	for _, item := range items {
+		if err := encoder.Encode(item.Card()); nil != err {
+			return nil, err
+		}
+	}
+	return buffer.Bytes(), nil
+}
+
+// Card convert profile into vCard, the legacy single phone and address are
+// used when the contact entries are not loaded
+func (p *PhoneBook) Card() *vcard.Card {
+	card := &vcard.Card{
+		UID: p.ID.String(),
+	}
+
+	if nil != p.Fullname {
+		card.FormattedName = *p.Fullname
+	}
+
+	if nil != p.UpdatedDateUTC {
+		card.Revision = p.UpdatedDateUTC.UTC().Format("20060102T150405Z")
+	} else if nil != p.CreatedDateUTC {
+		card.Revision = p.CreatedDateUTC.UTC().Format("20060102T150405Z")
+	}
+
+	for _, phone := range p.Phones {
+		number := phone.Number
+		if nil != phone.NumberE164 {
+			number = *phone.NumberE164
+		}
+		card.Tels = append(card.Tels, vcardValue(phone.Label, phone.Primary, number))
+	}
+	if len(p.Phones) == 0 && nil != p.PhoneNumber {
+		number := *p.PhoneNumber
+		if nil != p.PhoneNumberE164 {
+			number = *p.PhoneNumberE164
+		}
+		card.Tels = append(card.Tels, vcardValue(LabelMobile, true, number))
+	}
+
+	for _, email := range p.Emails {
+		card.Emails = append(card.Emails, vcardValue(email.Label, email.Primary, email.Email))
+	}
+
+	for _, address := range p.Addresses {
+		card.Addresses = append(card.Addresses, vcardValue(address.Label, address.Primary, address.Address))
+	}
+	if len(p.Addresses) == 0 && nil != p.Address && *p.Address != "" {
+		card.Addresses = append(card.Addresses, vcardValue(LabelHome, true, *p.Address))
+	}
+
+	return card
+}
+
+func vcardValue(label string, primary bool, value string) vcard.Value {
+	v := vcard.Value{
+		Preferred: primary,
+		Value:     value,
+	}
+	if t, ok := vcardTypes[label]; ok {
+		v.Types = []string{t}
+	}
+	return v
+}
+
+// PhoneBookFromCard convert vCard into profile, phones, emails and addresses are
+// kept as entries so they are normalized like a created profile
+func PhoneBookFromCard(card *vcard.Card) *PhoneBook {
+	data := &PhoneBook{
+		Phones:    make([]*ContactPhone, 0, len(card.Tels)),
+		Emails:    make([]*ContactEmail, 0, len(card.Emails)),
+		Addresses: make([]*ContactAddress, 0, len(card.Addresses)),
+	}
+
+	if card.FormattedName != "" {
+		name := card.FormattedName
+		data.Fullname = &name
+	}
+
+	for _, tel := range card.Tels {
+		data.Phones = append(data.Phones, &ContactPhone{
+			Label:   cardLabel(tel.Types, LabelMobile),
+			Number:  tel.Value,
+			Primary: tel.Preferred,
+		})
+	}
+
+	for _, email := range card.Emails {
+		data.Emails = append(data.Emails, &ContactEmail{
+			Label:   cardLabel(email.Types, LabelOther),
+			Email:   email.Value,
+			Primary: email.Preferred,
+		})
+	}
+
+	for _, address := range card.Addresses {
+		if address.Value == "" {
+			continue
+		}
+		data.Addresses = append(data.Addresses, &ContactAddress{
+			Label:   cardLabel(address.Types, LabelHome),
+			Address: address.Value,
+			Primary: address.Preferred,
+		})
+	}
+
+	return data
+}
+
+// cardLabel label of the first known TYPE, mobile is only valid for phones
+func cardLabel(types []string, fallback string) string {
+	for _, t := range types {
+		switch t {
+		case "cell":
+			if fallback == LabelMobile {
+				return LabelMobile
+			}
+		case "work":
+			return LabelWork
+		case "home":
+			return LabelHome
+		}
+	}
+	return fallback
+}
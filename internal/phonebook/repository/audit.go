@@ -0,0 +1,125 @@
+package repository
+
+import (
+	// internal golang package
+	"context"
+	"database/sql"
+	"encoding/json"
+	"reflect"
+	"sort"
+
+	// internal package
+	"phonebook/internal/global"
+	"phonebook/internal/phonebook/model"
+	"phonebook/pkg/queryable"
+
+	// thirdparty package
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// auditIgnored bookkeeping fields changed by every mutation, they are not reported as changed
+var auditIgnored = map[string]bool{
+	"version":          true,
+	"updated_date_utc": true,
+	"updated_by":       true,
+}
+
+// ListAudit , get audit trail of profile, deleted profiles included
+func (r *Repository) ListAudit(ctx context.Context, id uuid.UUID) ([]*model.AuditEntry, error) {
+	result := make([]*model.AuditEntry, 0)
+	err := global.GetQuery(ctx, r.db).SelectContext(ctx, &result, `SELECT id, phone_book_id, action, actor, changed_fields, before, after, created_date_utc
+	FROM phone_book_audit WHERE phone_book_id = $1 ORDER BY id`, id)
+	if nil != err {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// selectPerson get profile with its contacts inside the transaction of a mutation, deleted profiles included.
+// The row is locked so the snapshot taken before the mutation can not be changed by another transaction
+func selectPerson(ctx context.Context, q queryable.Q, id uuid.UUID) (*model.PhoneBook, error) {
+	result := &model.PhoneBook{}
+	err := q.GetContext(ctx, result, `SELECT * FROM phone_book WHERE id = $1 FOR UPDATE`, id)
+	if sql.ErrNoRows == err {
+		return nil, nil
+	}
+	if nil != err {
+		return nil, err
+	}
+
+	err = loadContacts(ctx, q, []*model.PhoneBook{result})
+	if nil != err {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// writeAudit record mutation of profile in phone_book_audit, in the transaction of the mutation
+func writeAudit(ctx context.Context, q queryable.Q, action string, actor *string, before *model.PhoneBook, after *model.PhoneBook) error {
+	id := uuid.Nil
+	if nil != after {
+		id = after.ID
+	} else if nil != before {
+		id = before.ID
+	}
+
+	beforeJSON, beforeFields, err := auditSnapshot(before)
+	if nil != err {
+		return err
+	}
+
+	afterJSON, afterFields, err := auditSnapshot(after)
+	if nil != err {
+		return err
+	}
+
+	changed := make(pq.StringArray, 0)
+	for field, value := range afterFields {
+		if !auditIgnored[field] && !reflect.DeepEqual(value, beforeFields[field]) {
+			changed = append(changed, field)
+		}
+	}
+	for field := range beforeFields {
+		if _, ok := afterFields[field]; !ok && !auditIgnored[field] {
+			changed = append(changed, field)
+		}
+	}
+	sort.Strings(changed)
+
+	_, err = q.ExecContext(ctx, `INSERT INTO phone_book_audit (phone_book_id, action, actor, changed_fields, before, after)
+	VALUES ($1, $2, $3, $4, $5, $6)`, id, action, actor, changed, beforeJSON, afterJSON)
+	return err
+}
+
+// auditSnapshot json of profile together with its fields for comparison. Contact entries get new
+// ids on every save, so ids are left out of the comparison
+func auditSnapshot(data *model.PhoneBook) (interface{}, map[string]interface{}, error) {
+	fields := make(map[string]interface{})
+	if nil == data {
+		return nil, fields, nil
+	}
+
+	raw, err := json.Marshal(data)
+	if nil != err {
+		return nil, nil, err
+	}
+
+	err = json.Unmarshal(raw, &fields)
+	if nil != err {
+		return nil, nil, err
+	}
+
+	for _, name := range []string{"phones", "emails", "addresses"} {
+		entries, _ := fields[name].([]interface{})
+		for _, entry := range entries {
+			if m, ok := entry.(map[string]interface{}); ok {
+				delete(m, "id")
+			}
+		}
+	}
+
+	return string(raw), fields, nil
+}
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	// internal golang package
+	"context"
+
+	// internal package
+	"phonebook/internal/phonebook/model"
+	"phonebook/pkg/queryable"
+
+	// thirdparty package
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// loadContacts fill phones, emails and addresses of every profile in items
+func loadContacts(ctx context.Context, q queryable.Q, items []*model.PhoneBook) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	ids := make(pq.StringArray, 0, len(items))
+	byID := make(map[uuid.UUID]*model.PhoneBook, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ID.String())
+		byID[item.ID] = item
+		item.Phones = make([]*model.ContactPhone, 0)
+		item.Emails = make([]*model.ContactEmail, 0)
+		item.Addresses = make([]*model.ContactAddress, 0)
+	}
+
+	phones := make([]*model.ContactPhone, 0)
+	err := q.SelectContext(ctx, &phones, `SELECT id, phone_book_id, label, number, number_e164, is_primary
+	FROM phone_book_phone WHERE phone_book_id = ANY($1::uuid[]) ORDER BY is_primary DESC, label`, ids)
+	if nil != err {
+		return err
+	}
+	for _, phone := range phones {
+		byID[phone.PhoneBookID].Phones = append(byID[phone.PhoneBookID].Phones, phone)
+	}
+
+	emails := make([]*model.ContactEmail, 0)
+	err = q.SelectContext(ctx, &emails, `SELECT id, phone_book_id, label, email, is_primary
+	FROM phone_book_email WHERE phone_book_id = ANY($1::uuid[]) ORDER BY is_primary DESC, label`, ids)
+	if nil != err {
+		return err
+	}
+	for _, email := range emails {
+		byID[email.PhoneBookID].Emails = append(byID[email.PhoneBookID].Emails, email)
+	}
+
+	addresses := make([]*model.ContactAddress, 0)
+	err = q.SelectContext(ctx, &addresses, `SELECT id, phone_book_id, label, address, is_primary
+	FROM phone_book_address WHERE phone_book_id = ANY($1::uuid[]) ORDER BY is_primary DESC, label`, ids)
+	if nil != err {
+		return err
+	}
+	for _, address := range addresses {
+		byID[address.PhoneBookID].Addresses = append(byID[address.PhoneBookID].Addresses, address)
+	}
+
+	return nil
+}
+
+// saveContacts replace stored phones, emails and addresses of data, a nil slice is left untouched.
+// Entries always get new ids so ids sent by the caller can not collide with other contacts
+func saveContacts(ctx context.Context, q queryable.Q, data *model.PhoneBook) error {
+	var err error
+
+	if nil != data.Phones {
+		_, err = q.ExecContext(ctx, `DELETE FROM phone_book_phone WHERE phone_book_id = $1`, data.ID)
+		if nil != err {
+			return err
+		}
+
+		for _, phone := range data.Phones {
+			phone.PhoneBookID = data.ID
+			if phone.ID, err = uuid.NewRandom(); nil != err {
+				return err
+			}
+
+			_, err = q.NamedExecContext(ctx, `INSERT INTO phone_book_phone (id, phone_book_id, label, number, number_e164, is_primary)
+			VALUES (:id, :phone_book_id, :label, :number, :number_e164, :is_primary)`, phone)
+			if nil != err {
+				return err
+			}
+		}
+	}
+
+	if nil != data.Emails {
+		_, err = q.ExecContext(ctx, `DELETE FROM phone_book_email WHERE phone_book_id = $1`, data.ID)
+		if nil != err {
+			return err
+		}
+
+		for _, email := range data.Emails {
+			email.PhoneBookID = data.ID
+			if email.ID, err = uuid.NewRandom(); nil != err {
+				return err
+			}
+
+			_, err = q.NamedExecContext(ctx, `INSERT INTO phone_book_email (id, phone_book_id, label, email, is_primary)
+			VALUES (:id, :phone_book_id, :label, :email, :is_primary)`, email)
+			if nil != err {
+				return err
+			}
+		}
+	}
+
+	if nil != data.Addresses {
+		_, err = q.ExecContext(ctx, `DELETE FROM phone_book_address WHERE phone_book_id = $1`, data.ID)
+		if nil != err {
+			return err
+		}
+
+		for _, address := range data.Addresses {
+			address.PhoneBookID = data.ID
+			if address.ID, err = uuid.NewRandom(); nil != err {
+				return err
+			}
+
+			_, err = q.NamedExecContext(ctx, `INSERT INTO phone_book_address (id, phone_book_id, label, address, is_primary)
+			VALUES (:id, :phone_book_id, :label, :address, :is_primary)`, address)
+			if nil != err {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
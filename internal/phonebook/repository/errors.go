@@ -0,0 +1,88 @@
+package repository
+
+import (
+	// internal golang package
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+
+	// internal package
+	"phonebook/internal/global"
+	"phonebook/pkg/queryable"
+
+	// thirdparty package
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	uniqueViolation       = "23505"
+	uniquePhoneConstraint = "ux_phone_book_phone_number_e164"
+	uniquePhoneColumn     = "number_e164"
+)
+
+// uniqueDetailRegex columns and values of the violated key, the index also cover tenant_id
+// so the number is picked by column and the tenant is not reported
+var uniqueDetailRegex = regexp.MustCompile(`Key \((.*)\)=\((.*)\) already exists`)
+
+// DuplicatePhoneError phone number is already used by another contact, ContactID is
+// uuid.Nil when that contact could not be read
+type DuplicatePhoneError struct {
+	Number    string
+	ContactID uuid.UUID
+}
+
+func (e *DuplicatePhoneError) Error() string {
+	if uuid.Nil == e.ContactID {
+		return "phone number " + e.Number + " is already used by another contact"
+	}
+	return "phone number " + e.Number + " is already used by " + e.ContactID.String()
+}
+
+// duplicatePhoneNumber return the conflicting number when err violates the unique phone index
+func duplicatePhoneNumber(err error) (string, bool) {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Code != uniqueViolation || pqErr.Constraint != uniquePhoneConstraint {
+		return "", false
+	}
+
+	match := uniqueDetailRegex.FindStringSubmatch(pqErr.Detail)
+	if len(match) < 3 {
+		return "", true
+	}
+
+	columns := strings.Split(match[1], ", ")
+	values := strings.Split(match[2], ", ")
+	for i, column := range columns {
+		if column == uniquePhoneColumn && len(columns) == len(values) {
+			return values[i], true
+		}
+	}
+
+	// a number in E.164 has no comma, it is the last value whatever precede it
+	return values[len(values)-1], true
+}
+
+// translateError turn unique violation of phone number into DuplicatePhoneError
+func (r *Repository) translateError(ctx context.Context, err error) error {
+	number, ok := duplicatePhoneNumber(err)
+	if !ok {
+		return err
+	}
+
+	// the transaction or savepoint of err is rolled back by now, the conflicting
+	// contact is committed by the time the violation is raised so it is read in a
+	// fresh one. The violation is reported even when that lookup fails, only
+	// without the conflicting contact
+	dup := &DuplicatePhoneError{Number: number}
+	lookup := queryable.RunInTransaction(ctx, r.db, func(ctx context.Context) error {
+		return global.GetQuery(ctx, r.db).GetContext(ctx, &dup.ContactID, `SELECT phone_book_id FROM phone_book_phone
+		WHERE number_e164 = $1 AND deleted_date_utc IS NULL`, number)
+	})
+	if nil != lookup {
+		dup.ContactID = uuid.Nil
+	}
+
+	return dup
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	// internal golang package
+	"errors"
+	"fmt"
+	"testing"
+
+	// thirdparty package
+	"github.com/lib/pq"
+)
+
+func TestDuplicatePhoneNumber(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		number string
+		ok     bool
+	}{
+		{
+			name: "composite index of migration 11",
+			err: &pq.Error{Code: uniqueViolation, Constraint: uniquePhoneConstraint,
+				Detail: "Key (tenant_id, number_e164)=(acme, +6281234567890) already exists."},
+			number: "+6281234567890",
+			ok:     true,
+		},
+		{
+			name: "index on the number only",
+			err: &pq.Error{Code: uniqueViolation, Constraint: uniquePhoneConstraint,
+				Detail: "Key (number_e164)=(+6281234567890) already exists."},
+			number: "+6281234567890",
+			ok:     true,
+		},
+		{
+			name: "tenant with a comma",
+			err: &pq.Error{Code: uniqueViolation, Constraint: uniquePhoneConstraint,
+				Detail: "Key (tenant_id, number_e164)=(acme, inc, +6281234567890) already exists."},
+			number: "+6281234567890",
+			ok:     true,
+		},
+		{
+			name: "wrapped by the transaction",
+			err: fmt.Errorf("error when committing transaction: %w", &pq.Error{Code: uniqueViolation,
+				Constraint: uniquePhoneConstraint, Detail: "Key (tenant_id, number_e164)=(acme, +6281234567890) already exists."}),
+			number: "+6281234567890",
+			ok:     true,
+		},
+		{
+			name:   "detail missing",
+			err:    &pq.Error{Code: uniqueViolation, Constraint: uniquePhoneConstraint},
+			number: "",
+			ok:     true,
+		},
+		{
+			name: "other constraint",
+			err: &pq.Error{Code: uniqueViolation, Constraint: "pk_phone_book",
+				Detail: "Key (id)=(1) already exists."},
+		},
+		{
+			name: "other error",
+			err:  errors.New("connection refused"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			number, ok := duplicatePhoneNumber(test.err)
+			if number != test.number || ok != test.ok {
+				t.Errorf("got (%q, %v), want (%q, %v)", number, ok, test.number, test.ok)
+			}
+		})
+	}
+}
@@ -4,21 +4,33 @@ import (
 	// internal golang package
 	"bytes"
 	"context"
-	"time"
+	"database/sql"
+	"strconv"
 
 	// internal package
 	"phonebook/internal/global"
 	"phonebook/internal/phonebook/model"
+	"phonebook/pkg/queryable"
 
 	// thirdparty package
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
-type Repository struct{}
+// phoneBookColumns every column of phone_book, in the order of model.PhoneBook
+var phoneBookColumns = []string{
+	`id`, `fullname`, `phone_number`, `phone_number_e164`, `address`,
+	`created_date_utc`, `created_by`, `updated_date_utc`, `updated_by`,
+	`deleted_date_utc`, `deleted_by`, `version`,
+}
+
+type Repository struct {
+	db *sqlx.DB
+}
 
-func NewPostgres() *Repository {
-	return &Repository{}
+func NewPostgres(db *sqlx.DB) *Repository {
+	return &Repository{db: db}
 }
 
 // ListPhoneBook , get list of phone book
@@ -26,181 +38,343 @@ func (r *Repository) ListPhoneBook(ctx context.Context, getparams *model.GetPhon
 	result := make([]*model.PhoneBook, 0)
 
 	var rows *sqlx.Rows
-	var params = make([]interface{}, 0)
 	var err error
-	var buffer, selectBuffer bytes.Buffer
-	var first bool
-	var count int
-	var createdDateUTC time.Time
 
-	buffer.WriteString(`SELECT id, fullname, phone_number,
-	address, created_date_utc, created_by, updated_date_utc, updated_by
-	 FROM phone_book WHERE`)
+	builder := filterPhoneBook(queryable.Select(`phone_book`, phoneBookColumns...), getparams)
+
+	order := `ASC`
+	if nil != getparams.Position {
+		condition := `(created_date_utc, id) > (:cursor_created_date_utc, :cursor_id)`
+		if getparams.Position.Backward {
+			order = `DESC`
+			condition = `(created_date_utc, id) < (:cursor_created_date_utc, :cursor_id)`
+		}
+		builder.Where(condition, queryable.Params{
+			"cursor_created_date_utc": getparams.Position.CreatedDateUTC,
+			"cursor_id":               getparams.Position.ID,
+		})
+	}
+
+	builder.OrderBy(`created_date_utc `+order, `id `+order)
 
-	if uuid.Nil != getparams.OffsetID {
-		selectBuffer.WriteString(`SELECT created_date_utc FROM phone_book WHERE id = :id`)
-		rows, err = global.DB().NamedQuery(selectBuffer.String(), getparams.OffsetID)
+	if nil != getparams.Limit {
+		builder.Limit(*getparams.Limit)
+	}
+
+	query, params := builder.Build()
+	rows, err = global.GetQuery(ctx, r.db).NamedQueryContext(ctx, query, params)
+	if nil != err {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		phone := &model.PhoneBook{}
+		err = rows.Scan(&phone.ID, &phone.Fullname, &phone.PhoneNumber, &phone.PhoneNumberE164, &phone.Address, &phone.CreatedDateUTC, &phone.CreatedBy, &phone.UpdatedDateUTC, &phone.UpdatedBy, &phone.DeletedDateUTC, &phone.DeletedBy, &phone.Version)
 		if nil != err {
 			return nil, err
 		}
+		result = append(result, phone)
+	}
 
-		for rows.Next() {
-			err = rows.Scan(&createdDateUTC)
-			if nil != err {
-				return nil, err
-			}
+	if err = rows.Err(); nil != err {
+		return nil, err
+	}
+
+	err = loadContacts(ctx, global.GetQuery(ctx, r.db), result)
+	if nil != err {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// CountPhoneBook , count phone book matching the filter, cursor and limit are ignored
+func (r *Repository) CountPhoneBook(ctx context.Context, getparams *model.GetPhoneList) (int, error) {
+	var rows *sqlx.Rows
+	var err error
+	var count int
+
+	query, params := filterPhoneBook(queryable.Select(`phone_book`), getparams).Count()
+	rows, err = global.GetQuery(ctx, r.db).NamedQueryContext(ctx, query, params)
+	if nil != err {
+		return 0, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		err = rows.Scan(&count)
+		if nil != err {
+			return 0, err
 		}
 	}
 
-	if uuid.Nil != getparams.ID {
-		first = false
-		count++
-		buffer.WriteString(`id = :id`)
-		params = append(params, getparams.ID)
-	} else if nil != getparams.Fullname {
-		if !first {
-			buffer.WriteString(`AND`)
+	return count, rows.Err()
+}
+
+// StreamPhoneBook , pass phone book matching the filter to fn in chunks of size rows. Rows are
+// fetched through a server side cursor so the whole result is never held in memory
+func (r *Repository) StreamPhoneBook(ctx context.Context, getparams *model.GetPhoneList, size int, fn func([]*model.PhoneBook) error) error {
+	query, params := filterPhoneBook(queryable.Select(`phone_book`, phoneBookColumns...), getparams).
+		OrderBy(`created_date_utc ASC`, `id ASC`).
+		Build()
+
+	return queryable.RunInTransaction(ctx, r.db, func(ctx context.Context) error {
+		q := global.GetQuery(ctx, r.db)
+
+		query, args, err := q.BindNamed(`DECLARE phone_book_stream NO SCROLL CURSOR FOR `+query, params)
+		if nil != err {
+			return err
 		}
-		buffer.WriteString(`fullname ilike :fullname`)
-		params = append(params, getparams.Fullname)
-		first = false
-	} else if nil != getparams.PhoneNumber {
-		if !first {
-			buffer.WriteString(`AND`)
+
+		_, err = q.ExecContext(ctx, query, args...)
+		if nil != err {
+			return err
 		}
-		buffer.WriteString(`phone_number like :phone_number`)
-		params = append(params, getparams.Address)
-	} else if uuid.Nil != getparams.OffsetID {
-		if !first {
-			buffer.WriteString(`AND`)
+
+		fetch := `FETCH FORWARD ` + strconv.Itoa(size) + ` FROM phone_book_stream`
+		for {
+			chunk := make([]*model.PhoneBook, 0, size)
+			err = q.SelectContext(ctx, &chunk, fetch)
+			if nil != err {
+				return err
+			}
+
+			if len(chunk) == 0 {
+				break
+			}
+
+			err = loadContacts(ctx, q, chunk)
+			if nil != err {
+				return err
+			}
+
+			err = fn(chunk)
+			if nil != err {
+				return err
+			}
 		}
-		buffer.WriteString(`created_date_utc > :created_date_utc  `)
-		params = append(params, createdDateUTC)
+
+		_, err = q.ExecContext(ctx, `CLOSE phone_book_stream`)
+		return err
+	})
+}
+
+// filterPhoneBook add every supplied filter of getparams to builder
+func filterPhoneBook(builder *queryable.Builder, getparams *model.GetPhoneList) *queryable.Builder {
+	if getparams.OnlyDeleted {
+		builder.Where(`deleted_date_utc IS NOT NULL`, nil)
+	} else {
+		builder.Where(`deleted_date_utc IS NULL`, nil)
 	}
 
-	buffer.WriteString(` deleted_date_utc IS NULL `)
+	if uuid.Nil != getparams.ID {
+		builder.Where(`id = :id`, queryable.Params{"id": getparams.ID})
+	}
 
-	if nil != getparams.Limit {
-		buffer.WriteString(`LIMIT :limit`)
-		params = append(params, getparams.Limit)
+	if nil != getparams.Fullname {
+		builder.Where(`fullname ilike :fullname`, queryable.Params{"fullname": *getparams.Fullname})
 	}
 
-	buffer.WriteString(`ORDER BY created_date_utc`)
+	if nil != getparams.PhoneNumber {
+		builder.Where(`EXISTS (SELECT 1 FROM phone_book_phone
+		WHERE phone_book_phone.phone_book_id = phone_book.id
+		AND (phone_book_phone.number like :phone_number OR phone_book_phone.number_e164 like :phone_number))`,
+			queryable.Params{"phone_number": *getparams.PhoneNumber})
+	}
 
-	if ctx == nil {
-		rows, err = global.DB().NamedQuery(buffer.String(), params)
-	} else {
-		rows, err = global.DB().NamedQueryContext(ctx, buffer.String(), params)
+	if len(getparams.PhonesE164) > 0 {
+		builder.Where(`EXISTS (SELECT 1 FROM phone_book_phone
+		WHERE phone_book_phone.phone_book_id = phone_book.id AND phone_book_phone.number_e164 = ANY(CAST(:phones_e164 AS varchar[])))`,
+			queryable.Params{"phones_e164": pq.StringArray(getparams.PhonesE164)})
 	}
 
-	if nil != err {
-		return nil, err
+	if nil != getparams.Address {
+		builder.Where(`address ilike :address`, queryable.Params{"address": *getparams.Address})
 	}
 
-	for rows.Next() {
-		phone := &model.PhoneBook{}
-		err = rows.Scan(&phone.ID, &phone.Fullname, &phone.PhoneNumber, &phone.Address, &phone.CreatedDateUTC, &phone.CreatedBy, &phone.UpdatedDateUTC, &phone.UpdatedBy)
-		if nil != err {
-			return nil, err
+	// members of any of the groups
+	if len(getparams.Groups) > 0 {
+		groups := make(pq.StringArray, 0, len(getparams.Groups))
+		for _, group := range getparams.Groups {
+			groups = append(groups, group.String())
 		}
-		result = append(result, phone)
+		builder.Where(`EXISTS (SELECT 1 FROM group_member
+		WHERE group_member.phone_book_id = phone_book.id AND group_member.group_id = ANY(CAST(:groups AS uuid[])))`,
+			queryable.Params{"groups": groups})
 	}
 
-	return result, nil
+	return builder
 }
 
-// AddingPerson , adding new person to phone book
+// AddingPerson , adding new person to phone book together with its phones, emails and addresses
 func (r *Repository) AddingPerson(ctx context.Context, data *model.PhoneBook) error {
-	var err error
-	var buffer bytes.Buffer
-	var args = make([]interface{}, 0)
+	err := queryable.RunInTransaction(ctx, r.db, func(ctx context.Context) error {
+		return insertPerson(ctx, global.GetQuery(ctx, r.db), data)
+	})
 
-	buffer.WriteString(` INSERT INTO phone_book ( id, fullname, phone_number, address, created_by, updated_by) 
-	VALUES (:id, :fullname, :phone_number, :address, :created_by, :updated_by)`)
-	args = append(args, data.ID, data.Fullname, data.PhoneNumber, data.Address, data.CreatedBy, data.UpdatedBy)
-	if nil != ctx {
-		_, err = global.DB().NamedExecContext(ctx, buffer.String(), args)
-	} else {
-		_, err = global.DB().NamedExec(buffer.String(), args)
+	return r.translateError(ctx, err)
+}
+
+// AddingPeople , adding batch of people in one transaction, nothing is saved when one of them fails
+func (r *Repository) AddingPeople(ctx context.Context, data []*model.PhoneBook) error {
+	err := queryable.RunInTransaction(ctx, r.db, func(ctx context.Context) error {
+		q := global.GetQuery(ctx, r.db)
+		for _, person := range data {
+			if err := insertPerson(ctx, q, person); nil != err {
+				return err
+			}
+		}
+		return nil
+	})
+
+	return r.translateError(ctx, err)
+}
+
+func insertPerson(ctx context.Context, q queryable.Q, data *model.PhoneBook) error {
+	_, err := q.NamedExecContext(ctx, ` INSERT INTO phone_book ( id, fullname, phone_number, phone_number_e164, address, created_by, updated_by)
+	VALUES (:id, :fullname, :phone_number, :phone_number_e164, :address, :created_by, :updated_by)`, data)
+	if err != nil {
+		return err
 	}
 
+	err = saveContacts(ctx, q, data)
 	if err != nil {
 		return err
 	}
 
-	return nil
-}
+	after, err := selectPerson(ctx, q, data.ID)
+	if err != nil {
+		return err
+	}
 
-// UpdatePerson , update profile of person
-func (r *Repository) UpdatePerson(ctx context.Context, data *model.PhoneBook) error {
-	var err error
-	var buffer bytes.Buffer
-	var args = make([]interface{}, 0)
-	var first bool
+	return writeAudit(ctx, q, model.AuditCreate, data.CreatedBy, nil, after)
+}
 
-	buffer.WriteString(` UPDATE phone_book SET `)
+// UpdatePerson , update every supplied field of person in one statement and return the updated profile.
+// When data.Version is set the row is only updated if it still has that version, otherwise nil is returned
+func (r *Repository) UpdatePerson(ctx context.Context, data *model.PhoneBook) (result *model.PhoneBook, err error) {
+	builder := queryable.Update(`phone_book`)
 	if nil != data.Fullname {
-		buffer.WriteString(` fullname = :fullname `)
-		args = append(args, data.Fullname)
-		first = false
-	} else if nil != data.PhoneNumber {
-		if !first {
-			buffer.WriteString(`,`)
-		}
-		buffer.WriteString(` phone_number = :phone_number `)
-		args = append(args, data.PhoneNumber)
-		first = false
-	} else if nil != data.Address {
-		if !first {
-			buffer.WriteString(`, `)
-		}
-		buffer.WriteString(` address = :address`)
-		args = append(args, data.Address)
-		first = false
-	} else if nil != data.UpdatedBy {
-		buffer.WriteString(` updated_by = :updated_by`)
-		args = append(args, data.UpdatedBy)
-	}
-
-	buffer.WriteString(`WHERE id = :id`)
-	args = append(args, data.ID)
-
-	if nil != ctx {
-		_, err = global.DB().NamedExecContext(ctx, buffer.String(), args)
-	} else {
-		_, err = global.DB().NamedExec(buffer.String(), args)
+		builder.Set(`fullname`, *data.Fullname)
+	}
+	if nil != data.PhoneNumber {
+		builder.Set(`phone_number`, *data.PhoneNumber)
+		builder.Set(`phone_number_e164`, data.PhoneNumberE164)
+	}
+	if nil != data.Address {
+		builder.Set(`address`, *data.Address)
 	}
 
-	if nil != err {
-		return err
+	if 0 != data.Version {
+		builder.Where(`version = :version`, queryable.Params{"version": data.Version})
 	}
 
-	return nil
-}
+	query, params := builder.
+		SetExpr(`version`, `version + 1`).
+		SetExpr(`updated_date_utc`, `CURRENT_TIMESTAMP`).
+		Set(`updated_by`, data.UpdatedBy).
+		Where(`id = :id`, queryable.Params{"id": data.ID}).
+		Where(`deleted_date_utc IS NULL`, nil).
+		Returning(phoneBookColumns...).
+		Build()
+
+	err = queryable.RunInTransaction(ctx, r.db, func(ctx context.Context) error {
+		q := global.GetQuery(ctx, r.db)
+
+		before, err := selectPerson(ctx, q, data.ID)
+		if nil != err || nil == before {
+			return err
+		}
 
-// RemoveData , remove profile but set deleted date utc
-func (r *Repository) RemoveData(ctx context.Context, data *model.PhoneBook) error {
-	var err error
-	var buffer bytes.Buffer
-	var args = make([]interface{}, 0)
+		query, args, err := q.BindNamed(query, params)
+		if nil != err {
+			return err
+		}
+
+		updated := &model.PhoneBook{}
+		err = q.GetContext(ctx, updated, query, args...)
+		if sql.ErrNoRows == err {
+			return nil
+		}
+		if nil != err {
+			return err
+		}
 
-	buffer.WriteString(`UPDATE phone_book SET deleted_date_utc = :deleted_date_utc , deleted_by = :deleted_by
-	WHERE id = :id`)
+		err = saveContacts(ctx, q, data)
+		if nil != err {
+			return err
+		}
 
-	args = append(args, data.DeletedDateUTC, data.DeletedBy, data.ID)
+		err = loadContacts(ctx, q, []*model.PhoneBook{updated})
+		if nil != err {
+			return err
+		}
 
-	if nil != ctx {
-		_, err = global.DB().NamedExecContext(ctx, buffer.String(), args)
-	} else {
-		_, err = global.DB().NamedExec(buffer.String(), args)
-	}
+		err = writeAudit(ctx, q, model.AuditUpdate, data.UpdatedBy, before, updated)
+		if nil != err {
+			return err
+		}
 
-	if nil != err {
-		return err
+		result = updated
+		return nil
+	})
+
+	return result, r.translateError(ctx, err)
+}
+
+// RemoveData , remove profile but set deleted date utc, its phones are released for other contacts.
+// When data.Version is set the row is only removed if it still has that version,
+// removed is false when no row matched
+func (r *Repository) RemoveData(ctx context.Context, data *model.PhoneBook) (removed bool, err error) {
+	builder := queryable.Update(`phone_book`).
+		SetExpr(`deleted_date_utc`, `CURRENT_TIMESTAMP`).
+		Set(`deleted_by`, data.DeletedBy).
+		SetExpr(`version`, `version + 1`).
+		Where(`id = :id`, queryable.Params{"id": data.ID}).
+		Where(`deleted_date_utc IS NULL`, nil)
+
+	if 0 != data.Version {
+		builder.Where(`version = :version`, queryable.Params{"version": data.Version})
 	}
 
-	return nil
+	query, params := builder.Build()
+
+	err = queryable.RunInTransaction(ctx, r.db, func(ctx context.Context) error {
+		q := global.GetQuery(ctx, r.db)
+
+		before, err := selectPerson(ctx, q, data.ID)
+		if nil != err || nil == before {
+			return err
+		}
+
+		result, err := q.NamedExecContext(ctx, query, params)
+		if nil != err {
+			return err
+		}
+
+		affected, err := result.RowsAffected()
+		if nil != err || 0 == affected {
+			return err
+		}
+
+		_, err = q.ExecContext(ctx, `UPDATE phone_book_phone SET deleted_date_utc = CURRENT_TIMESTAMP
+		WHERE phone_book_id = $1 AND deleted_date_utc IS NULL`, data.ID)
+		if nil != err {
+			return err
+		}
+
+		after, err := selectPerson(ctx, q, data.ID)
+		if nil != err {
+			return err
+		}
 
+		err = writeAudit(ctx, q, model.AuditDelete, data.DeletedBy, before, after)
+		removed = nil == err
+		return err
+	})
+
+	return removed, err
 }
 
 // FetchByID , get one profile from phone book
@@ -210,12 +384,16 @@ func (r *Repository) FetchByID(ctx context.Context, id uuid.UUID) (*model.PhoneB
 	var result = &model.PhoneBook{}
 
 	buffer.WriteString(`SELECT * FROM phone_book WHERE id = $1 AND deleted_date_utc IS NULL`)
-	if nil != ctx {
-		err = global.DB().GetContext(ctx, *result, buffer.String(), id)
-	} else {
-		err = global.DB().Get(&result, buffer.String(), id)
+	err = global.GetQuery(ctx, r.db).GetContext(ctx, result, buffer.String(), id)
+	if sql.ErrNoRows == err {
+		return nil, nil
+	}
+
+	if nil != err {
+		return nil, err
 	}
 
+	err = loadContacts(ctx, global.GetQuery(ctx, r.db), []*model.PhoneBook{result})
 	if nil != err {
 		return nil, err
 	}
@@ -0,0 +1,216 @@
+package repository
+
+import (
+	// internal golang package
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	// internal package
+	"phonebook/internal/phonebook/model"
+	"phonebook/pkg/queryable"
+	"phonebook/pkg/tenant"
+
+	// thirdparty package
+	"github.com/golang-migrate/migrate"
+	"github.com/golang-migrate/migrate/database/postgres"
+	_ "github.com/golang-migrate/migrate/source/file"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// errStep2 failure of the step following the insert
+var errStep2 = errors.New("step 2 failed")
+
+// openTestDB connect to TEST_DB_CONNECTION_STRING and migrate it, the test is skipped
+// when it is not set. The caller close it
+func openTestDB(t *testing.T) *sqlx.DB {
+	dsn := os.Getenv("TEST_DB_CONNECTION_STRING")
+	if dsn == "" {
+		t.Skip("TEST_DB_CONNECTION_STRING is not set")
+	}
+
+	db, err := sqlx.Connect("postgres", dsn)
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	driver, err := postgres.WithInstance(db.DB, &postgres.Config{})
+	if nil != err {
+		t.Fatal(err)
+	}
+	m, err := migrate.NewWithDatabaseInstance("file://../../database/migrations", "postgres", driver)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if err = m.Up(); nil != err && migrate.ErrNoChange != err {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+// testContext context of a tenant of its own, so rows of other runs are not visible
+func testContext() context.Context {
+	return tenant.NewContext(context.Background(), "test-"+uuid.New().String())
+}
+
+func newPerson(name string) *model.PhoneBook {
+	id := uuid.New()
+	empty := ""
+	actor := "test"
+	return &model.PhoneBook{
+		ID:          id,
+		Fullname:    &name,
+		PhoneNumber: &empty,
+		Address:     &empty,
+		CreatedBy:   &actor,
+		UpdatedBy:   &actor,
+	}
+}
+
+// exists whether person is visible in a new transaction of ctx
+func exists(t *testing.T, ctx context.Context, r *Repository, id uuid.UUID) bool {
+	var found *model.PhoneBook
+	err := queryable.RunInTransaction(ctx, r.db, func(ctx context.Context) (err error) {
+		found, err = r.FetchByID(ctx, id)
+		return err
+	})
+	if nil != err {
+		t.Fatal(err)
+	}
+	return nil != found
+}
+
+func TestRunInTransactionRollback(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+	r := NewPostgres(db)
+	ctx := testContext()
+	person := newPerson("rolled back")
+
+	err := queryable.RunInTransaction(ctx, r.db, func(ctx context.Context) error {
+		if err := r.AddingPerson(ctx, person); nil != err {
+			return err
+		}
+		return errStep2
+	})
+	if errStep2 != err {
+		t.Fatalf("got error %v, want %v", err, errStep2)
+	}
+
+	if exists(t, ctx, r, person.ID) {
+		t.Error("person inserted before the failing step is still stored")
+	}
+}
+
+func TestRunInTransactionSavepointRollback(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+	r := NewPostgres(db)
+	ctx := testContext()
+	kept := newPerson("kept")
+	rolledBack := newPerson("rolled back")
+
+	err := queryable.RunInTransaction(ctx, r.db, func(ctx context.Context) error {
+		if err := r.AddingPerson(ctx, kept); nil != err {
+			return err
+		}
+
+		err := queryable.RunInTransaction(ctx, r.db, func(ctx context.Context) error {
+			if err := r.AddingPerson(ctx, rolledBack); nil != err {
+				return err
+			}
+			return errStep2
+		})
+		if errStep2 != err {
+			t.Errorf("got error %v from savepoint, want %v", err, errStep2)
+		}
+
+		// the outer transaction is still usable once the savepoint is rolled back
+		return nil
+	})
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	if !exists(t, ctx, r, kept.ID) {
+		t.Error("person inserted by the outer transaction is not stored")
+	}
+	if exists(t, ctx, r, rolledBack.ID) {
+		t.Error("person inserted inside the rolled back savepoint is still stored")
+	}
+}
+
+func withPhone(person *model.PhoneBook, number string) *model.PhoneBook {
+	person.Phones = []*model.ContactPhone{{Label: "mobile", Number: number, NumberE164: &number, Primary: true}}
+	return person
+}
+
+func TestAddingPersonDuplicatePhone(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+	r := NewPostgres(db)
+	ctx := testContext()
+	number := "+6281234567890"
+
+	first := withPhone(newPerson("first"), number)
+	if err := r.AddingPerson(ctx, first); nil != err {
+		t.Fatal(err)
+	}
+
+	err := r.AddingPerson(ctx, withPhone(newPerson("second"), number))
+	var dup *DuplicatePhoneError
+	if !errors.As(err, &dup) {
+		t.Fatalf("got error %v, want DuplicatePhoneError", err)
+	}
+	if dup.Number != number {
+		t.Errorf("got number %q, want %q", dup.Number, number)
+	}
+	if dup.ContactID != first.ID {
+		t.Errorf("got contact %s, want %s", dup.ContactID, first.ID)
+	}
+
+	// the index is per tenant, another tenant may use the same number
+	if err = r.AddingPerson(testContext(), withPhone(newPerson("other tenant"), number)); nil != err {
+		t.Errorf("got error %v in another tenant, want none", err)
+	}
+}
+
+func TestRunInTransactionRetrySerializationFailure(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+	r := NewPostgres(db)
+	ctx := testContext()
+
+	attempts := 0
+	persons := make([]*model.PhoneBook, 0)
+	err := queryable.RunInTransaction(ctx, r.db, func(ctx context.Context) error {
+		attempts++
+		person := newPerson("retried")
+		persons = append(persons, person)
+		if err := r.AddingPerson(ctx, person); nil != err {
+			return err
+		}
+		if attempts == 1 {
+			return &pq.Error{Code: "40001", Message: "could not serialize access"}
+		}
+		return nil
+	}, queryable.Retry(queryable.DefaultRetries, time.Millisecond))
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	if attempts != 2 {
+		t.Fatalf("got %d attempts, want 2", attempts)
+	}
+	if exists(t, ctx, r, persons[0].ID) {
+		t.Error("person inserted by the failed attempt is still stored")
+	}
+	if !exists(t, ctx, r, persons[1].ID) {
+		t.Error("person inserted by the retried attempt is not stored")
+	}
+}
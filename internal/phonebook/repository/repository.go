@@ -3,6 +3,7 @@ package repository
 import (
 	// internal golang package
 	"context"
+	"time"
 
 	// internal package
 	"phonebook/internal/phonebook/model"
@@ -12,8 +13,16 @@ import (
 
 type Interface interface {
 	ListPhoneBook(ctx context.Context, params *model.GetPhoneList) ([]*model.PhoneBook, error)
+	SearchPhoneBook(ctx context.Context, params *model.SearchPhoneBook) ([]*model.SearchResult, error)
+	CountPhoneBook(ctx context.Context, params *model.GetPhoneList) (int, error)
+	StreamPhoneBook(ctx context.Context, params *model.GetPhoneList, size int, fn func([]*model.PhoneBook) error) error
 	AddingPerson(ctx context.Context, data *model.PhoneBook) error
-	UpdatePerson(ctx context.Context, data *model.PhoneBook) error
-	RemoveData(ctx context.Context, data *model.PhoneBook) error
+	AddingPeople(ctx context.Context, data []*model.PhoneBook) error
+	UpdatePerson(ctx context.Context, data *model.PhoneBook) (*model.PhoneBook, error)
+	RemoveData(ctx context.Context, data *model.PhoneBook) (bool, error)
 	FetchByID(ctx context.Context, id uuid.UUID) (*model.PhoneBook, error)
+	RestorePerson(ctx context.Context, data *model.PhoneBook) (*model.PhoneBook, error)
+	PurgePerson(ctx context.Context, id uuid.UUID, actor string) (bool, error)
+	PurgeDeleted(ctx context.Context, deletedBefore time.Time, limit int, actor string) (int, error)
+	ListAudit(ctx context.Context, id uuid.UUID) ([]*model.AuditEntry, error)
 }
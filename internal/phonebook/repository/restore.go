@@ -0,0 +1,95 @@
+package repository
+
+import (
+	// internal golang package
+	"context"
+	"strconv"
+	"time"
+
+	// internal package
+	"phonebook/internal/global"
+	"phonebook/internal/phonebook/model"
+	"phonebook/pkg/queryable"
+	"phonebook/pkg/tenant"
+
+	// thirdparty package
+	"github.com/google/uuid"
+)
+
+// RestorePerson , undo soft delete of profile and take its phones back. A phone registered by another
+// contact in the meantime is reported as DuplicatePhoneError, nil is returned when the profile is not deleted
+func (r *Repository) RestorePerson(ctx context.Context, data *model.PhoneBook) (result *model.PhoneBook, err error) {
+	err = queryable.RunInTransaction(ctx, r.db, func(ctx context.Context) error {
+		q := global.GetQuery(ctx, r.db)
+
+		before, err := selectPerson(ctx, q, data.ID)
+		if nil != err || nil == before || nil == before.DeletedDateUTC {
+			return err
+		}
+
+		_, err = q.ExecContext(ctx, `UPDATE phone_book SET
+			deleted_date_utc = NULL, deleted_by = NULL, version = version + 1,
+			updated_date_utc = CURRENT_TIMESTAMP, updated_by = $2
+		WHERE id = $1`, data.ID, data.UpdatedBy)
+		if nil != err {
+			return err
+		}
+
+		// the unique index on E.164 number reject phones taken by another contact
+		_, err = q.ExecContext(ctx, `UPDATE phone_book_phone SET deleted_date_utc = NULL WHERE phone_book_id = $1`, data.ID)
+		if nil != err {
+			return err
+		}
+
+		after, err := selectPerson(ctx, q, data.ID)
+		if nil != err {
+			return err
+		}
+
+		err = writeAudit(ctx, q, model.AuditRestore, data.UpdatedBy, before, after)
+		if nil != err {
+			return err
+		}
+
+		result = after
+		return nil
+	})
+
+	return result, r.translateError(ctx, err)
+}
+
+// PurgePerson , permanently remove soft deleted profile with its contact entries, purged is false
+// when the profile is not deleted. Snapshots of its audit trail are erased, the actions are kept
+func (r *Repository) PurgePerson(ctx context.Context, id uuid.UUID, actor string) (bool, error) {
+	count, err := r.purge(ctx, `id = $1 AND deleted_date_utc IS NOT NULL`, id, actor)
+	return count > 0, err
+}
+
+// PurgeDeleted , permanently remove at most limit profiles soft deleted before deletedBefore
+// and return how many were removed. Profiles of every tenant are purged
+func (r *Repository) PurgeDeleted(ctx context.Context, deletedBefore time.Time, limit int, actor string) (count int, err error) {
+	err = queryable.RunInTransaction(tenant.System(ctx), r.db, func(ctx context.Context) error {
+		count, err = r.purge(ctx, `deleted_date_utc < $1 ORDER BY deleted_date_utc LIMIT `+strconv.Itoa(limit), deletedBefore, actor)
+		return err
+	})
+	return count, err
+}
+
+// purge delete profiles matching condition, whose only argument is $1, in one statement
+func (r *Repository) purge(ctx context.Context, condition string, arg interface{}, actor string) (int, error) {
+	var count int
+	err := global.GetQuery(ctx, r.db).GetContext(ctx, &count, `WITH purged AS (
+		DELETE FROM phone_book WHERE id IN (
+			SELECT id FROM phone_book WHERE `+condition+` FOR UPDATE SKIP LOCKED
+		) RETURNING id, tenant_id
+	), scrubbed AS (
+		UPDATE phone_book_audit SET before = NULL, after = NULL
+		WHERE phone_book_id IN (SELECT id FROM purged)
+	), logged AS (
+		INSERT INTO phone_book_audit (tenant_id, phone_book_id, action, actor)
+		SELECT tenant_id, id, '`+model.AuditPurge+`', $2 FROM purged
+	)
+	SELECT count(*) FROM purged`, arg, actor)
+
+	return count, err
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	// internal golang package
+	"context"
+
+	// internal package
+	"phonebook/internal/global"
+	"phonebook/internal/phonebook/model"
+	"phonebook/pkg/queryable"
+)
+
+// escapeHTML sql expression escaping column as html text. Highlights wrap matches in
+// <mark>, so the stored text must not be able to add markup of its own
+func escapeHTML(column string) string {
+	return `replace(replace(replace(replace(replace(` + column +
+		`, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;'), '''', '&#39;')`
+}
+
+// searchQuery rank contacts by full text match, trigram similarity and phone prefix.
+// :tsquery and :phone_prefix are empty when the query does not contain words or digits.
+// Highlighted text is html escaped
+var searchQuery = `SELECT phone_book.id, fullname, phone_number, phone_number_e164, address,
+	created_date_utc, created_by, updated_date_utc, updated_by, version,
+	(
+		CASE WHEN :tsquery <> '' THEN ts_rank(to_tsvector('simple', fullname || ' ' || address), to_tsquery('simple', :tsquery)) ELSE 0 END
+		+ greatest(similarity(fullname, :q), similarity(address, :q))
+		+ CASE WHEN phone.phone_book_id IS NOT NULL THEN 1 ELSE 0 END
+	) AS rank,
+	CASE WHEN :tsquery <> '' THEN ts_headline('simple', ` + escapeHTML(`fullname`) + `, to_tsquery('simple', :tsquery), 'StartSel=<mark>, StopSel=</mark>, HighlightAll=true') END AS fullname_highlight,
+	CASE WHEN :tsquery <> '' THEN ts_headline('simple', ` + escapeHTML(`address`) + `, to_tsquery('simple', :tsquery), 'StartSel=<mark>, StopSel=</mark>, HighlightAll=true') END AS address_highlight
+FROM phone_book
+LEFT JOIN LATERAL (
+	SELECT phone_book_id FROM phone_book_phone
+	WHERE phone_book_phone.phone_book_id = phone_book.id
+	AND phone_book_phone.deleted_date_utc IS NULL
+	AND :phone_prefix <> '' AND phone_book_phone.number_e164 LIKE :phone_prefix || '%'
+	LIMIT 1
+) AS phone ON TRUE
+WHERE phone_book.deleted_date_utc IS NULL AND (
+	(:tsquery <> '' AND to_tsvector('simple', fullname || ' ' || address) @@ to_tsquery('simple', :tsquery))
+	OR fullname % :q
+	OR address % :q
+	OR phone.phone_book_id IS NOT NULL
+)
+ORDER BY rank DESC, created_date_utc, phone_book.id
+LIMIT :limit`
+
+// SearchPhoneBook , search contacts ranked by relevance
+func (r *Repository) SearchPhoneBook(ctx context.Context, params *model.SearchPhoneBook) ([]*model.SearchResult, error) {
+	result := make([]*model.SearchResult, 0)
+
+	limit := 0
+	if nil != params.Limit {
+		limit = *params.Limit
+	}
+
+	rows, err := global.GetQuery(ctx, r.db).NamedQueryContext(ctx, searchQuery, queryable.Params{
+		"q":            params.Query,
+		"tsquery":      params.TSQuery,
+		"phone_prefix": params.PhonePrefix,
+		"limit":        limit,
+	})
+	if nil != err {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]*model.PhoneBook, 0)
+	for rows.Next() {
+		item := &model.SearchResult{}
+		err = rows.StructScan(item)
+		if nil != err {
+			return nil, err
+		}
+		result = append(result, item)
+		items = append(items, item.PhoneBook)
+	}
+
+	if err = rows.Err(); nil != err {
+		return nil, err
+	}
+
+	err = loadContacts(ctx, global.GetQuery(ctx, r.db), items)
+	if nil != err {
+		return nil, err
+	}
+
+	return result, nil
+}
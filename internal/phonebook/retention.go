@@ -0,0 +1,62 @@
+package phonebook
+
+import (
+	// internal golang package
+	"context"
+	"time"
+
+	// internal package
+	"phonebook/config"
+)
+
+// PurgeExpired permanently remove profiles removed more than RetentionDays ago, batch by batch,
+// and return how many were removed. Nothing is removed when RetentionDays is not positive
+func (svc *Service) PurgeExpired(ctx context.Context) (int, error) {
+	cfg, err := config.Get()
+	if nil != err {
+		return 0, err
+	}
+
+	if cfg.RetentionDays <= 0 {
+		return 0, nil
+	}
+
+	deletedBefore := time.Now().AddDate(0, 0, -cfg.RetentionDays)
+	total := 0
+	for nil == ctx.Err() {
+		count, err := svc.repo.PurgeDeleted(ctx, deletedBefore, cfg.RetentionBatchSize, svc.actor(ctx))
+		total += count
+		if nil != err {
+			return total, err
+		}
+
+		if count < cfg.RetentionBatchSize {
+			break
+		}
+	}
+
+	return total, nil
+}
+
+// RunRetention run PurgeExpired every RetentionInterval until ctx is done
+func (svc *Service) RunRetention(ctx context.Context) error {
+	cfg, err := config.Get()
+	if nil != err {
+		return err
+	}
+
+	for {
+		count, err := svc.PurgeExpired(ctx)
+		if nil != err {
+			svc.Logger.Log("action", "purge_expired", "err", err)
+		} else if count > 0 {
+			svc.Logger.Log("action", "purge_expired", "purged", count)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(cfg.RetentionInterval):
+		}
+	}
+}
@@ -4,26 +4,38 @@ import (
 	// internal golang package
 	"context"
 	"errors"
+	"strconv"
+	"strings"
+	"unicode"
 
 	// internal package
+	"phonebook/config"
 	"phonebook/internal/phonebook/model"
 	"phonebook/internal/phonebook/repository"
+	"phonebook/pkg/auth"
+	"phonebook/pkg/cursor"
+	pkghttp "phonebook/pkg/http"
+	"phonebook/pkg/httperror"
+	"phonebook/pkg/validator"
 
 	// thirdparty package
 	"github.com/go-kit/kit/log"
 	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
 )
 
 type Service struct {
 	Actor  string
 	Logger log.Logger
+	DB     *sqlx.DB
 	repo   repository.Interface
 }
 
-func NewService(repo repository.Interface, actor string, logger log.Logger) *Service {
+func NewService(db *sqlx.DB, repo repository.Interface, actor string, logger log.Logger) *Service {
 	return &Service{
 		Actor:  actor,
 		Logger: logger,
+		DB:     db,
 		repo:   repo,
 	}
 }
@@ -37,37 +49,243 @@ func (svc *Service) CreatePhoneAddress(ctx context.Context, data *model.PhoneBoo
 
 	data.ID = id
 
-	params := &model.GetPhoneList{
-		PhoneNumber: data.PhoneNumber,
-	}
+	actor := svc.actor(ctx)
+	data.CreatedBy = &actor
 
-	result, err := svc.repo.ListPhoneBook(ctx, params)
+	err = normalizeContacts(data, nil)
 	if nil != err {
 		return err
 	}
 
-	if result != nil {
-		return errors.New("phone_already_registered")
-	}
-
+	// duplicates are rejected by the unique index on E.164 number, which is race free
 	err = svc.repo.AddingPerson(ctx, data)
 	if nil != err {
-		return err
+		return conflict(err)
 	}
 
 	return nil
 }
 
-// FetchData fetching data of phone book
-func (svc *Service) FetchData(ctx context.Context, params *model.GetPhoneList) ([]*model.PhoneBook, error) {
-	result, err := svc.repo.ListPhoneBook(ctx, params)
+// FetchData fetching one page of phone book, ordered by created date
+func (svc *Service) FetchData(ctx context.Context, params *model.GetPhoneList) (*model.PhoneBookPage, error) {
+	cfg, err := config.Get()
+	if nil != err {
+		return nil, err
+	}
+
+	secret := []byte(cfg.CursorSecret)
+
+	if nil != params.Cursor {
+		params.Position = &model.Position{}
+		err = cursor.Decode(secret, *params.Cursor, params.Position)
+		if nil != err {
+			return nil, ErrInvalidCursor.Wrap(err)
+		}
+	}
+
+	limit := cfg.DefaultPageSize
+	if nil != params.Limit && *params.Limit > 0 {
+		limit = *params.Limit
+	}
+	if limit > cfg.MaxPageSize {
+		limit = cfg.MaxPageSize
+	}
+
+	// fetch one more row to know whether another page exists
+	fetch := limit + 1
+	params.Limit = &fetch
+
+	items, err := svc.repo.ListPhoneBook(ctx, params)
+	if nil != err {
+		return nil, err
+	}
+
+	page := &model.PhoneBookPage{
+		HasMore: len(items) > limit,
+	}
+	if page.HasMore {
+		items = items[:limit]
+	}
+
+	backward := nil != params.Position && params.Position.Backward
+	if backward {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+	}
+	page.Items = items
+
+	if len(items) > 0 {
+		// moving backward always leaves rows ahead, moving forward from a cursor always leaves rows behind
+		if page.HasMore || backward {
+			page.NextCursor, err = encodeCursor(secret, items[len(items)-1], false)
+			if nil != err {
+				return nil, err
+			}
+		}
+		if (page.HasMore && backward) || (nil != params.Position && !backward) {
+			page.PrevCursor, err = encodeCursor(secret, items[0], true)
+			if nil != err {
+				return nil, err
+			}
+		}
+	}
+
+	if params.WithTotal {
+		total, err := svc.repo.CountPhoneBook(ctx, params)
+		if nil != err {
+			return nil, err
+		}
+		page.Total = &total
+	}
+
+	return page, nil
+}
+
+func encodeCursor(secret []byte, phone *model.PhoneBook, backward bool) (*string, error) {
+	position := &model.Position{
+		ID:       phone.ID,
+		Backward: backward,
+	}
+	if nil != phone.CreatedDateUTC {
+		position.CreatedDateUTC = *phone.CreatedDateUTC
+	}
+
+	c, err := cursor.Encode(secret, position)
+	if nil != err {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// ExportData fetching every profile matching the filter of params, cursor and limit are ignored.
+// The whole export is held in memory, so more than ExportSyncMax profiles are refused and
+// have to be exported by an export job
+func (svc *Service) ExportData(ctx context.Context, params *model.GetPhoneList) (*model.PhoneBookExport, error) {
+	cfg, err := config.Get()
+	if nil != err {
+		return nil, err
+	}
+
+	params.Cursor = nil
+	params.Position = nil
+	params.Limit = nil
+
+	count, err := svc.repo.CountPhoneBook(ctx, params)
+	if nil != err {
+		return nil, err
+	}
+
+	if count > cfg.ExportSyncMax {
+		return nil, ErrExportTooLarge.WithDetails(map[string]string{
+			"count": strconv.Itoa(count),
+			"max":   strconv.Itoa(cfg.ExportSyncMax),
+		})
+	}
+
+	// rows added since the count are left out rather than growing the response
+	limit := cfg.ExportSyncMax
+	params.Limit = &limit
+	items, err := svc.repo.ListPhoneBook(ctx, params)
+	if nil != err {
+		return nil, err
+	}
+
+	return &model.PhoneBookExport{
+		Items: items,
+	}, nil
+}
+
+// CountData count profiles matching the filter of params
+func (svc *Service) CountData(ctx context.Context, params *model.GetPhoneList) (int, error) {
+	return svc.repo.CountPhoneBook(ctx, params)
+}
+
+// StreamData pass every profile matching the filter of params to fn, size profiles at a time
+func (svc *Service) StreamData(ctx context.Context, params *model.GetPhoneList, size int, fn func([]*model.PhoneBook) error) error {
+	return svc.repo.StreamPhoneBook(ctx, params, size, fn)
+}
+
+// SearchData search contacts by name, phone and address, tolerating typos
+func (svc *Service) SearchData(ctx context.Context, params *model.SearchPhoneBook) (*model.SearchResponse, error) {
+	cfg, err := config.Get()
+	if nil != err {
+		return nil, err
+	}
+
+	limit := cfg.DefaultPageSize
+	if nil != params.Limit && *params.Limit > 0 {
+		limit = *params.Limit
+	}
+	if limit > cfg.MaxPageSize {
+		limit = cfg.MaxPageSize
+	}
+	params.Limit = &limit
+
+	// every word is matched as prefix, e.g. "jo smi" find "John Smith"
+	words := strings.FieldsFunc(strings.ToLower(params.Query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i := range words {
+		words[i] = words[i] + ":*"
+	}
+	params.TSQuery = strings.Join(words, " & ")
+
+	// a query made only of at least three digits is also matched as phone prefix
+	digits := 0
+	for _, r := range params.Query {
+		if unicode.IsDigit(r) {
+			digits++
+		}
+	}
+	if prefix, err := validator.PhonePrefix(params.Query, validator.PhoneRegion()); nil == err && digits >= 3 {
+		params.PhonePrefix = prefix
+	}
+
+	items, err := svc.repo.SearchPhoneBook(ctx, params)
+	if nil != err {
+		return nil, err
+	}
+
+	return &model.SearchResponse{
+		Items: items,
+	}, nil
+}
+
+// FetchByID fetching one profile of phone book
+func (svc *Service) FetchByID(ctx context.Context, id uuid.UUID) (*model.PhoneBook, error) {
+	result, err := svc.repo.FetchByID(ctx, id)
 	if nil != err {
 		return nil, err
 	}
 
+	if result == nil {
+		return nil, ErrProfileNotExist
+	}
+
 	return result, nil
 }
 
+// HistoryData audit trail of profile, removed profiles keep their history
+func (svc *Service) HistoryData(ctx context.Context, id uuid.UUID) (*model.History, error) {
+	items, err := svc.repo.ListAudit(ctx, id)
+	if nil != err {
+		return nil, err
+	}
+
+	// profiles created before the audit trail have no entries yet
+	if len(items) == 0 {
+		if _, err = svc.FetchByID(ctx, id); nil != err {
+			return nil, err
+		}
+	}
+
+	return &model.History{
+		Items: items,
+	}, nil
+}
+
 // RemoveData , remove profile on phone book
 func (svc *Service) RemoveData(ctx context.Context, data *model.PhoneBook) error {
 	res, err := svc.repo.FetchByID(ctx, data.ID)
@@ -76,35 +294,219 @@ func (svc *Service) RemoveData(ctx context.Context, data *model.PhoneBook) error
 	}
 
 	if res == nil {
-		return errors.New("profile_not_exist")
+		return ErrProfileNotExist
+	}
+
+	version, err := precondition(data.IfMatch, res)
+	if nil != err {
+		return err
 	}
 
-	err = svc.repo.RemoveData(ctx, &model.PhoneBook{
-		ID: data.ID,
+	actor := svc.actor(ctx)
+	removed, err := svc.repo.RemoveData(ctx, &model.PhoneBook{
+		ID:        data.ID,
+		DeletedBy: &actor,
+		Version:   version,
 	})
 
 	if nil != err {
 		return err
 	}
 
+	if !removed {
+		return ErrVersionMismatch
+	}
+
 	return nil
 }
 
-// UpdateData update data profile
-func (svc *Service) UpdateData(ctx context.Context, data *model.PhoneBook) error {
-	res, err := svc.repo.FetchByID(ctx, data.ID)
+// ListDeleted fetching one page of removed profiles, ordered by created date
+func (svc *Service) ListDeleted(ctx context.Context, params *model.GetPhoneList) (*model.PhoneBookPage, error) {
+	params.OnlyDeleted = true
+	return svc.FetchData(ctx, params)
+}
+
+// RestoreData undo removal of profile, it fails when one of its phones has been registered by another contact
+func (svc *Service) RestoreData(ctx context.Context, id uuid.UUID) (*model.PhoneBook, error) {
+	actor := svc.actor(ctx)
+	result, err := svc.repo.RestorePerson(ctx, &model.PhoneBook{
+		ID:        id,
+		UpdatedBy: &actor,
+	})
+	if nil != err {
+		return nil, conflict(err)
+	}
+
+	if nil == result {
+		return nil, svc.notDeleted(ctx, id)
+	}
+
+	return result, nil
+}
+
+// PurgeData permanently remove profile, only removed profiles can be purged
+func (svc *Service) PurgeData(ctx context.Context, id uuid.UUID) error {
+	purged, err := svc.repo.PurgePerson(ctx, id, svc.actor(ctx))
+	if nil != err {
+		return err
+	}
+
+	if !purged {
+		return svc.notDeleted(ctx, id)
+	}
+
+	return nil
+}
+
+// notDeleted error of profile that can not be restored or purged
+func (svc *Service) notDeleted(ctx context.Context, id uuid.UUID) error {
+	current, err := svc.repo.FetchByID(ctx, id)
 	if nil != err {
 		return err
 	}
 
+	if nil != current {
+		return ErrProfileNotDeleted
+	}
+
+	return ErrProfileNotExist
+}
+
+// UpdateData update data profile and return the updated profile
+func (svc *Service) UpdateData(ctx context.Context, data *model.PhoneBook) (*model.PhoneBook, error) {
+	res, err := svc.repo.FetchByID(ctx, data.ID)
+	if nil != err {
+		return nil, err
+	}
+
+	if res == nil {
+		return nil, ErrProfileNotExist
+	}
+
+	data.Version, err = precondition(data.IfMatch, res)
+	if nil != err {
+		return nil, err
+	}
+
+	err = normalizeContacts(data, res)
+	if nil != err {
+		return nil, err
+	}
+
+	actor := svc.actor(ctx)
+	data.UpdatedBy = &actor
+
+	result, err := svc.repo.UpdatePerson(ctx, data)
+	if nil != err {
+		return nil, conflict(err)
+	}
+
+	if result == nil {
+		return nil, noRowUpdated(data.Version)
+	}
+
+	return result, nil
+}
+
+// PatchData apply JSON Merge Patch to profile and return the updated profile
+func (svc *Service) PatchData(ctx context.Context, patch *model.PatchPhoneBook) (*model.PhoneBook, error) {
+	res, err := svc.repo.FetchByID(ctx, patch.ID)
+	if nil != err {
+		return nil, err
+	}
+
 	if res == nil {
-		return errors.New("profile_not_exist")
+		return nil, ErrProfileNotExist
+	}
+
+	// every patchable column is NOT NULL, so removing a member is not allowed
+	if nulls := patch.Nulls(); len(nulls) > 0 {
+		details := make(map[string]string)
+		for _, name := range nulls {
+			details[name] = name + " can not be null"
+		}
+		return nil, httperror.ErrValidation.WithDetails(details)
+	}
+
+	version, err := precondition(patch.IfMatch, res)
+	if nil != err {
+		return nil, err
+	}
+
+	if patch.Empty() {
+		return res, nil
+	}
+
+	actor := svc.actor(ctx)
+	data := &model.PhoneBook{
+		ID:          patch.ID,
+		Fullname:    patch.Fullname.Value,
+		PhoneNumber: patch.PhoneNumber.Value,
+		Address:     patch.Address.Value,
+		UpdatedBy:   &actor,
+		Version:     version,
+		Phones:      patch.Phones,
+		Emails:      patch.Emails,
+		Addresses:   patch.Addresses,
+	}
+
+	err = normalizeContacts(data, res)
+	if nil != err {
+		return nil, err
 	}
 
-	err = svc.repo.UpdatePerson(ctx, data)
+	result, err := svc.repo.UpdatePerson(ctx, data)
 	if nil != err {
+		return nil, conflict(err)
+	}
+
+	if result == nil {
+		return nil, noRowUpdated(data.Version)
+	}
+
+	return result, nil
+}
+
+// noRowUpdated error of an update that matched no row although the profile was read just
+// before. Without If-Match, version is zero and the profile can only have been deleted meanwhile
+func noRowUpdated(version int) error {
+	if 0 == version {
+		return ErrProfileNotExist
+	}
+	return ErrVersionMismatch
+}
+
+// precondition check If-Match header against current profile and return the
+// version the mutation must be conditioned on, zero when no header is sent
+func precondition(ifMatch string, current *model.PhoneBook) (int, error) {
+	if ifMatch == "" {
+		return 0, nil
+	}
+
+	if !pkghttp.MatchETag(ifMatch, current.ETag(), false) {
+		return 0, ErrVersionMismatch
+	}
+
+	return current.Version, nil
+}
+
+// conflict map duplicate phone of repository into catalogue error carrying the conflicting contact
+func conflict(err error) error {
+	var dup *repository.DuplicatePhoneError
+	if !errors.As(err, &dup) {
 		return err
 	}
 
-	return nil
+	details := map[string]string{"phone_number": dup.Number}
+	if uuid.Nil != dup.ContactID {
+		details["id"] = dup.ContactID.String()
+	}
+
+	return ErrPhoneAlreadyRegistered.Wrap(err).WithDetails(details)
+}
+
+// actor name recorded on created_by, updated_by and deleted_by, the authenticated principal
+// or the name of the process for background work
+func (svc *Service) actor(ctx context.Context) string {
+	return auth.Actor(ctx, svc.Actor)
 }
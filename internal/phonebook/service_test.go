@@ -0,0 +1,122 @@
+package phonebook
+
+import (
+	// internal golang package
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	// internal package
+	"phonebook/internal/phonebook/model"
+	"phonebook/internal/phonebook/repository"
+	"phonebook/pkg/httperror"
+
+	// thirdparty package
+	"github.com/go-kit/kit/log"
+	"github.com/google/uuid"
+)
+
+func TestConflict(t *testing.T) {
+	id := uuid.New()
+	dup := &repository.DuplicatePhoneError{Number: "+6281234567890", ContactID: id}
+	other := errors.New("connection refused")
+
+	tests := []struct {
+		name    string
+		err     error
+		details map[string]string
+	}{
+		{
+			name:    "duplicate",
+			err:     dup,
+			details: map[string]string{"phone_number": dup.Number, "id": id.String()},
+		},
+		{
+			name:    "wrapped duplicate",
+			err:     fmt.Errorf("error when committing transaction: %w", dup),
+			details: map[string]string{"phone_number": dup.Number, "id": id.String()},
+		},
+		{
+			name:    "duplicate without conflicting contact",
+			err:     &repository.DuplicatePhoneError{Number: dup.Number},
+			details: map[string]string{"phone_number": dup.Number},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var e *httperror.Error
+			if !errors.As(conflict(test.err), &e) || !errors.Is(e, ErrPhoneAlreadyRegistered) {
+				t.Fatalf("got %v, want %v", conflict(test.err), ErrPhoneAlreadyRegistered)
+			}
+			if fmt.Sprint(e.Details()) != fmt.Sprint(test.details) {
+				t.Errorf("got details %v, want %v", e.Details(), test.details)
+			}
+		})
+	}
+
+	if err := conflict(other); err != other {
+		t.Errorf("got %v, want %v unchanged", err, other)
+	}
+}
+
+// updateRepository repository whose profile is read but deleted before it is updated
+type updateRepository struct {
+	repository.Interface
+	current *model.PhoneBook
+	updated *model.PhoneBook
+}
+
+func (r *updateRepository) FetchByID(ctx context.Context, id uuid.UUID) (*model.PhoneBook, error) {
+	return r.current, nil
+}
+
+func (r *updateRepository) UpdatePerson(ctx context.Context, data *model.PhoneBook) (*model.PhoneBook, error) {
+	return r.updated, nil
+}
+
+func TestUpdateDataNoRowUpdated(t *testing.T) {
+	name := "name"
+	current := &model.PhoneBook{ID: uuid.New(), Fullname: &name, Version: 3}
+	svc := NewService(nil, &updateRepository{current: current}, "test", log.NewNopLogger())
+
+	tests := []struct {
+		name    string
+		ifMatch string
+		err     error
+	}{
+		{name: "deleted meanwhile without If-Match", err: ErrProfileNotExist},
+		{name: "changed meanwhile with If-Match", ifMatch: current.ETag(), err: ErrVersionMismatch},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := svc.UpdateData(context.Background(), &model.PhoneBook{ID: current.ID, Fullname: &name, IfMatch: test.ifMatch})
+			if !errors.Is(err, test.err) {
+				t.Errorf("got error %v, want %v", err, test.err)
+			}
+
+			_, err = svc.PatchData(context.Background(), &model.PatchPhoneBook{ID: current.ID,
+				Fullname: model.PatchString{Present: true, Value: &name}, IfMatch: test.ifMatch})
+			if !errors.Is(err, test.err) {
+				t.Errorf("got error %v from patch, want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestUpdateDataReturnProfile(t *testing.T) {
+	name := "name"
+	current := &model.PhoneBook{ID: uuid.New(), Fullname: &name, Version: 3}
+	updated := &model.PhoneBook{ID: current.ID, Fullname: &name, Version: 4}
+	svc := NewService(nil, &updateRepository{current: current, updated: updated}, "test", log.NewNopLogger())
+
+	result, err := svc.UpdateData(context.Background(), &model.PhoneBook{ID: current.ID, Fullname: &name})
+	if nil != err {
+		t.Fatal(err)
+	}
+	if result.Headers().Get("ETag") != updated.ETag() {
+		t.Errorf("got ETag %q, want %q", result.Headers().Get("ETag"), updated.ETag())
+	}
+}
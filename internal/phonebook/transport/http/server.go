@@ -6,13 +6,16 @@ import (
 	"phonebook/internal/phonebook"
 	"phonebook/internal/phonebook/endpoint"
 	"phonebook/internal/phonebook/model"
+	pkghttp "phonebook/pkg/http"
 	"phonebook/pkg/server"
+	"phonebook/pkg/vcard"
 
+	kitendpoint "github.com/go-kit/kit/endpoint"
 	"github.com/go-kit/kit/log"
 	kithttp "github.com/go-kit/kit/transport/http"
 )
 
-func Create(svc *phonebook.Service, logger log.Logger, opts []kithttp.ServerOption) http.Handler {
+func Create(svc *phonebook.Service, logger log.Logger, opts []kithttp.ServerOption, middlewares ...kitendpoint.Middleware) http.Handler {
 	end := endpoint.Add(svc)
 	var serverLogger *server.Logger
 	if nil != logger {
@@ -26,11 +29,12 @@ func Create(svc *phonebook.Service, logger log.Logger, opts []kithttp.ServerOpti
 	return server.NewHTTPServer(end, server.HTTPOption{
 		DecodeModel: &model.PhoneBook{},
 		Logger:      serverLogger,
+		Middlewares: middlewares,
 	}, opts...)
 }
 
-func Get(svc *phonebook.Service, logger log.Logger, opts []kithttp.ServerOption) http.Handler {
-	end := endpoint.Add(svc)
+func Get(svc *phonebook.Service, logger log.Logger, opts []kithttp.ServerOption, middlewares ...kitendpoint.Middleware) http.Handler {
+	end := endpoint.FetchData(svc)
 	var serverLogger *server.Logger
 	if nil != logger {
 		serverLogger = &server.Logger{
@@ -43,11 +47,125 @@ func Get(svc *phonebook.Service, logger log.Logger, opts []kithttp.ServerOption)
 	return server.NewHTTPServer(end, server.HTTPOption{
 		DecodeModel: &model.GetPhoneList{},
 		Logger:      serverLogger,
+		Middlewares: middlewares,
 	}, opts...)
 }
 
-func Update(svc *phonebook.Service, logger log.Logger, opts []kithttp.ServerOption) http.Handler {
-	end := endpoint.Add(svc)
+func Export(svc *phonebook.Service, logger log.Logger, opts []kithttp.ServerOption, middlewares ...kitendpoint.Middleware) http.Handler {
+	end := endpoint.Export(svc)
+	var serverLogger *server.Logger
+	if nil != logger {
+		serverLogger = &server.Logger{
+			Logger:    logger,
+			Namespace: "phonebook",
+			Subsystem: "export_vcard",
+			Action:    "GET",
+		}
+	}
+	opts = append(opts[:len(opts):len(opts)], kithttp.ServerBefore(pkghttp.ForceAccept(vcard.MediaType)))
+	return server.NewHTTPServer(end, server.HTTPOption{
+		DecodeModel: &model.GetPhoneList{},
+		Logger:      serverLogger,
+		Middlewares: middlewares,
+	}, opts...)
+}
+
+func Search(svc *phonebook.Service, logger log.Logger, opts []kithttp.ServerOption, middlewares ...kitendpoint.Middleware) http.Handler {
+	end := endpoint.Search(svc)
+	var serverLogger *server.Logger
+	if nil != logger {
+		serverLogger = &server.Logger{
+			Logger:    logger,
+			Namespace: "phonebook",
+			Subsystem: "search_profile",
+			Action:    "GET",
+		}
+	}
+	return server.NewHTTPServer(end, server.HTTPOption{
+		DecodeModel: &model.SearchPhoneBook{},
+		Logger:      serverLogger,
+		Middlewares: middlewares,
+	}, opts...)
+}
+
+func Import(svc *phonebook.Service, logger log.Logger, opts []kithttp.ServerOption, middlewares ...kitendpoint.Middleware) http.Handler {
+	end := endpoint.Import(svc)
+	var serverLogger *server.Logger
+	if nil != logger {
+		serverLogger = &server.Logger{
+			Logger:    logger,
+			Namespace: "phonebook",
+			Subsystem: "import_profile",
+			Action:    "POST",
+		}
+	}
+	return server.NewHTTPServer(end, server.HTTPOption{
+		DecodeModel: pkghttp.DecodeParam{
+			Model:   &model.ImportPhoneBook{},
+			Options: []pkghttp.DecodeOptions{pkghttp.GetBody("Body")},
+		},
+		Logger:      serverLogger,
+		Middlewares: middlewares,
+	}, opts...)
+}
+
+func FetchByID(svc *phonebook.Service, logger log.Logger, opts []kithttp.ServerOption, middlewares ...kitendpoint.Middleware) http.Handler {
+	end := endpoint.FetchByID(svc)
+	var serverLogger *server.Logger
+	if nil != logger {
+		serverLogger = &server.Logger{
+			Logger:    logger,
+			Namespace: "phonebook",
+			Subsystem: "get_profile",
+			Action:    "GET",
+		}
+	}
+	return server.NewHTTPServer(end, server.HTTPOption{
+		DecodeModel: &model.GetPhoneBook{},
+		Logger:      serverLogger,
+		Middlewares: middlewares,
+	}, opts...)
+}
+
+func FetchVCard(svc *phonebook.Service, logger log.Logger, opts []kithttp.ServerOption, middlewares ...kitendpoint.Middleware) http.Handler {
+	end := endpoint.FetchByID(svc)
+	var serverLogger *server.Logger
+	if nil != logger {
+		serverLogger = &server.Logger{
+			Logger:    logger,
+			Namespace: "phonebook",
+			Subsystem: "get_profile_vcard",
+			Action:    "GET",
+		}
+	}
+	opts = append(opts[:len(opts):len(opts)], kithttp.ServerBefore(pkghttp.ForceAccept(vcard.MediaType)))
+	return server.NewHTTPServer(end, server.HTTPOption{
+		DecodeModel: &model.GetPhoneBook{},
+		Logger:      serverLogger,
+		Middlewares: middlewares,
+	}, opts...)
+}
+
+func History(svc *phonebook.Service, logger log.Logger, opts []kithttp.ServerOption, middlewares ...kitendpoint.Middleware) http.Handler {
+	end := endpoint.History(svc)
+	var serverLogger *server.Logger
+	if nil != logger {
+		serverLogger = &server.Logger{
+			Logger:    logger,
+			Namespace: "phonebook",
+			Subsystem: "get_profile_history",
+			Action:    "GET",
+		}
+	}
+	return server.NewHTTPServer(end, server.HTTPOption{
+		DecodeModel: &model.GetHistory{},
+		Logger:      serverLogger,
+		Middlewares: middlewares,
+	}, opts...)
+}
+
+func Update(svc *phonebook.Service, logger log.Logger, opts []kithttp.ServerOption, middlewares ...kitendpoint.Middleware) http.Handler {
+	end := endpoint.Update(svc)
 	var serverLogger *server.Logger
 	if nil != logger {
 		serverLogger = &server.Logger{
@@ -60,22 +178,96 @@ func Update(svc *phonebook.Service, logger log.Logger, opts []kithttp.ServerOpti
 	return server.NewHTTPServer(end, server.HTTPOption{
 		DecodeModel: &model.PhoneBook{},
 		Logger:      serverLogger,
+		Middlewares: middlewares,
 	}, opts...)
 }
 
-func Remove(svc *phonebook.Service, logger log.Logger, opts []kithttp.ServerOption) http.Handler {
-	end := endpoint.Add(svc)
+func Patch(svc *phonebook.Service, logger log.Logger, opts []kithttp.ServerOption, middlewares ...kitendpoint.Middleware) http.Handler {
+	end := endpoint.Patch(svc)
+	var serverLogger *server.Logger
+	if nil != logger {
+		serverLogger = &server.Logger{
+			Logger:    logger,
+			Namespace: "phonebook",
+			Subsystem: "patch_profile",
+			Action:    "PATCH",
+		}
+	}
+	return server.NewHTTPServer(end, server.HTTPOption{
+		DecodeModel: &model.PatchPhoneBook{},
+		Logger:      serverLogger,
+		Middlewares: middlewares,
+	}, opts...)
+}
+
+func Remove(svc *phonebook.Service, logger log.Logger, opts []kithttp.ServerOption, middlewares ...kitendpoint.Middleware) http.Handler {
+	end := endpoint.Remove(svc)
 	var serverLogger *server.Logger
 	if nil != logger {
 		serverLogger = &server.Logger{
 			Logger:    logger,
 			Namespace: "phonebook",
 			Subsystem: "remove_profile",
-			Action:    "PUT",
+			Action:    "DELETE",
 		}
 	}
 	return server.NewHTTPServer(end, server.HTTPOption{
 		DecodeModel: &model.PhoneBook{},
 		Logger:      serverLogger,
+		Middlewares: middlewares,
+	}, opts...)
+}
+
+func ListDeleted(svc *phonebook.Service, logger log.Logger, opts []kithttp.ServerOption, middlewares ...kitendpoint.Middleware) http.Handler {
+	end := endpoint.ListDeleted(svc)
+	var serverLogger *server.Logger
+	if nil != logger {
+		serverLogger = &server.Logger{
+			Logger:    logger,
+			Namespace: "phonebook",
+			Subsystem: "list_deleted_profile",
+			Action:    "GET",
+		}
+	}
+	return server.NewHTTPServer(end, server.HTTPOption{
+		DecodeModel: &model.GetPhoneList{},
+		Logger:      serverLogger,
+		Middlewares: middlewares,
+	}, opts...)
+}
+
+func Restore(svc *phonebook.Service, logger log.Logger, opts []kithttp.ServerOption, middlewares ...kitendpoint.Middleware) http.Handler {
+	end := endpoint.Restore(svc)
+	var serverLogger *server.Logger
+	if nil != logger {
+		serverLogger = &server.Logger{
+			Logger:    logger,
+			Namespace: "phonebook",
+			Subsystem: "restore_profile",
+			Action:    "POST",
+		}
+	}
+	return server.NewHTTPServer(end, server.HTTPOption{
+		DecodeModel: &model.GetPhoneBook{},
+		Logger:      serverLogger,
+		Middlewares: middlewares,
+	}, opts...)
+}
+
+func Purge(svc *phonebook.Service, logger log.Logger, opts []kithttp.ServerOption, middlewares ...kitendpoint.Middleware) http.Handler {
+	end := endpoint.Purge(svc)
+	var serverLogger *server.Logger
+	if nil != logger {
+		serverLogger = &server.Logger{
+			Logger:    logger,
+			Namespace: "phonebook",
+			Subsystem: "purge_profile",
+			Action:    "DELETE",
+		}
+	}
+	return server.NewHTTPServer(end, server.HTTPOption{
+		DecodeModel: &model.GetPhoneBook{},
+		Logger:      serverLogger,
+		Middlewares: middlewares,
 	}, opts...)
 }
@@ -0,0 +1,239 @@
+package auth
+
+import (
+	// internal golang package
+	"context"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	// internal package
+	"phonebook/pkg/httperror"
+
+	// thirdparty package
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+// authentication method of principal
+const (
+	MethodJWT    = "jwt"
+	MethodAPIKey = "api_key"
+)
+
+// ErrNoCredentials request carry neither bearer token nor api key
+var ErrNoCredentials = errors.New("no credentials")
+
+// ErrInvalidCredentials token or api key is not valid
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// TenantClaim jwt claim binding token to a tenant
+const TenantClaim = "tenant_id"
+
+// CrossTenantClaim jwt claim allowing a token without TenantClaim to pick its tenant
+// per request, it must be true
+const CrossTenantClaim = "cross_tenant"
+
+// Principal authenticated caller of request, Tenant is empty when the
+// credentials are not bound to a tenant. Only a CrossTenant principal
+// may then act in a tenant of its choice
+type Principal struct {
+	ID          string
+	Name        string
+	Tenant      string
+	CrossTenant bool
+	Method      string
+	Claims      map[string]interface{}
+}
+
+type key int
+
+const principalKey key = 0
+
+// NewContext ...
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey, p)
+}
+
+// FromContext ...
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey).(*Principal)
+	return p, ok
+}
+
+// Actor name of principal in context recorded on created_by, updated_by and deleted_by,
+// fallback is used for work that is not started by a request
+func Actor(ctx context.Context, fallback string) string {
+	if p, ok := FromContext(ctx); ok && nil != p {
+		return p.Name
+	}
+	return fallback
+}
+
+// APIKey stored api key, only the hash of its secret is kept
+type APIKey struct {
+	TenantID   string `db:"tenant_id"`
+	ClientID   string `db:"client_id"`
+	Name       string `db:"name"`
+	SecretHash string `db:"secret_hash"`
+}
+
+// APIKeyStore find api key by client id, nil when it does not exist or is revoked
+type APIKeyStore interface {
+	FindAPIKey(ctx context.Context, clientID string) (*APIKey, error)
+}
+
+// HashSecret hash of api key secret as stored in database. Secrets are random
+// and long, so a single sha256 is enough and keeps lookup cheap
+func HashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// Options keys and claims accepted by Authenticator, a method without key is disabled
+type Options struct {
+	HMACSecret   []byte
+	RSAPublicKey *rsa.PublicKey
+	Issuer       string
+	Audience     string
+	APIKeys      APIKeyStore
+}
+
+// LoadRSAPublicKey read PEM encoded RSA public key from local file
+func LoadRSAPublicKey(path string) (*rsa.PublicKey, error) {
+	raw, err := ioutil.ReadFile(path)
+	if nil != err {
+		return nil, err
+	}
+	return jwt.ParseRSAPublicKeyFromPEM(raw)
+}
+
+// Authenticator validate bearer tokens and api keys of requests
+type Authenticator struct {
+	opts Options
+}
+
+// NewAuthenticator ...
+func NewAuthenticator(opts Options) *Authenticator {
+	return &Authenticator{opts: opts}
+}
+
+// Authenticate principal of request. Bearer token is read from Authorization,
+// api key from Client-ID and Client-Secret
+func (a *Authenticator) Authenticate(r *http.Request) (*Principal, error) {
+	if header := r.Header.Get("Authorization"); header != "" {
+		parts := strings.SplitN(header, " ", 2)
+		if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
+			return nil, ErrInvalidCredentials
+		}
+		return a.verifyToken(strings.TrimSpace(parts[1]))
+	}
+
+	clientID := r.Header.Get("Client-ID")
+	secret := r.Header.Get("Client-Secret")
+	if clientID != "" || secret != "" {
+		return a.verifyAPIKey(r.Context(), clientID, secret)
+	}
+
+	return nil, ErrNoCredentials
+}
+
+func (a *Authenticator) verifyToken(raw string) (*Principal, error) {
+	claims := jwt.MapClaims{}
+	token, err := jwt.ParseWithClaims(raw, claims, func(token *jwt.Token) (interface{}, error) {
+		// the algorithm is pinned by the key type so an RS256 key can never verify an HS256 token
+		switch token.Method {
+		case jwt.SigningMethodHS256:
+			if len(a.opts.HMACSecret) > 0 {
+				return a.opts.HMACSecret, nil
+			}
+		case jwt.SigningMethodRS256:
+			if nil != a.opts.RSAPublicKey {
+				return a.opts.RSAPublicKey, nil
+			}
+		}
+		return nil, ErrInvalidCredentials
+	})
+	if nil != err || !token.Valid {
+		return nil, ErrInvalidCredentials
+	}
+
+	if a.opts.Issuer != "" && !claims.VerifyIssuer(a.opts.Issuer, true) {
+		return nil, ErrInvalidCredentials
+	}
+	if a.opts.Audience != "" && !claims.VerifyAudience(a.opts.Audience, true) {
+		return nil, ErrInvalidCredentials
+	}
+
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	name := subject
+	for _, claim := range []string{"preferred_username", "name", "email"} {
+		if v, ok := claims[claim].(string); ok && v != "" {
+			name = v
+			break
+		}
+	}
+
+	tenant, _ := claims[TenantClaim].(string)
+	crossTenant, _ := claims[CrossTenantClaim].(bool)
+
+	return &Principal{
+		ID:          subject,
+		Name:        name,
+		Tenant:      tenant,
+		CrossTenant: crossTenant,
+		Method:      MethodJWT,
+		Claims:      claims,
+	}, nil
+}
+
+func (a *Authenticator) verifyAPIKey(ctx context.Context, clientID string, secret string) (*Principal, error) {
+	if nil == a.opts.APIKeys || clientID == "" || secret == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	apiKey, err := a.opts.APIKeys.FindAPIKey(ctx, clientID)
+	if nil != err {
+		return nil, err
+	}
+
+	if nil == apiKey || subtle.ConstantTimeCompare([]byte(HashSecret(secret)), []byte(apiKey.SecretHash)) != 1 {
+		return nil, ErrInvalidCredentials
+	}
+
+	return &Principal{
+		ID:     apiKey.ClientID,
+		Name:   apiKey.Name,
+		Tenant: apiKey.TenantID,
+		Method: MethodAPIKey,
+		Claims: map[string]interface{}{},
+	}, nil
+}
+
+// Middleware reject request without valid credentials with 401 and put principal in request context
+func Middleware(a *Authenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, err := a.Authenticate(r)
+			if nil != err {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="phonebook"`)
+				if ErrNoCredentials == err || ErrInvalidCredentials == err {
+					httperror.EncodeError(r.Context(), httperror.ErrUnauthorized, w)
+				} else {
+					httperror.EncodeError(r.Context(), err, w)
+				}
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), principal)))
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package cursor
+
+import (
+	// internal golang package
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// ErrInvalid cursor is malformed or its signature does not match
+var ErrInvalid = errors.New("invalid_cursor")
+
+var encoding = base64.RawURLEncoding
+
+// Encode marshal v and sign it with secret into an opaque cursor
+func Encode(secret []byte, v interface{}) (string, error) {
+	payload, err := json.Marshal(v)
+	if nil != err {
+		return "", err
+	}
+
+	return encoding.EncodeToString(payload) + "." + encoding.EncodeToString(sign(secret, payload)), nil
+}
+
+// Decode verify signature of cursor and unmarshal its payload into v
+func Decode(secret []byte, cursor string, v interface{}) error {
+	parts := strings.Split(cursor, ".")
+	if len(parts) != 2 {
+		return ErrInvalid
+	}
+
+	payload, err := encoding.DecodeString(parts[0])
+	if nil != err {
+		return ErrInvalid
+	}
+
+	signature, err := encoding.DecodeString(parts[1])
+	if nil != err {
+		return ErrInvalid
+	}
+
+	if !hmac.Equal(signature, sign(secret, payload)) {
+		return ErrInvalid
+	}
+
+	if err = json.Unmarshal(payload, v); nil != err {
+		return ErrInvalid
+	}
+
+	return nil
+}
+
+func sign(secret []byte, payload []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
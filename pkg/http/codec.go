@@ -0,0 +1,666 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	kithttp "github.com/go-kit/kit/transport/http"
+
+	"phonebook/pkg/httperror"
+	"phonebook/pkg/msgpack"
+	"phonebook/pkg/vcard"
+)
+
+//Codec encode responses and decode request bodies of one media type
+type Codec interface {
+	//ContentType value of Content-Type header of encoded responses
+	ContentType() string
+	//Supports whether response can be represented by the codec
+	Supports(response interface{}) bool
+	//Encode write response, params are the parameters of the accepted media range
+	Encode(w io.Writer, response interface{}, params map[string]string) error
+	//Decode read request body into model, ErrDecodeUnsupported when the codec is response only
+	Decode(r io.Reader, model interface{}) error
+}
+
+//CSVMarshaler list response that can be represented as csv records, the first record is the header
+type CSVMarshaler interface {
+	MarshalCSV() ([][]string, error)
+}
+
+//VCardMarshaler response that can be represented as vCard of the requested version
+type VCardMarshaler interface {
+	MarshalVCard(version string) ([]byte, error)
+}
+
+//ErrDecodeUnsupported codec can not read request bodies
+var ErrDecodeUnsupported = errors.New("decode is not supported")
+
+//negotiation errors
+var (
+	ErrNotAcceptable        = httperror.New(httperror.NotAcceptable, "not_acceptable", "none of the accepted media types can represent the response")
+	ErrUnsupportedMediaType = httperror.New(httperror.UnsupportedMediaType, "unsupported_media_type", "media type of request body is not supported")
+	ErrMalformedBody        = httperror.New(httperror.Invalid, "malformed_body", "request body can not be decoded")
+)
+
+type registeredCodec struct {
+	mediaType string
+	codec     Codec
+}
+
+var (
+	codecsMu sync.RWMutex
+	// the first codec is used when any media type is accepted
+	codecs = []registeredCodec{
+		{"application/json", jsonCodec{}},
+		{"application/merge-patch+json", jsonCodec{}},
+		{"application/xml", xmlCodec{}},
+		{"text/xml", xmlCodec{}},
+		{"text/csv", csvCodec{}},
+		{msgpack.MediaType, msgpackCodec{}},
+		{"application/x-msgpack", msgpackCodec{}},
+		{vcard.MediaType, vcardCodec{}},
+		{"text/x-vcard", vcardCodec{}},
+	}
+)
+
+//RegisterCodec register codec of media type, replacing the codec already registered for it
+func RegisterCodec(mediaType string, codec Codec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+
+	mediaType = strings.ToLower(mediaType)
+	for i := range codecs {
+		if codecs[i].mediaType == mediaType {
+			codecs[i].codec = codec
+			return
+		}
+	}
+	codecs = append(codecs, registeredCodec{mediaType, codec})
+}
+
+//codecFor codec decoding media type, structured syntax suffix fall back to its base codec
+func codecFor(mediaType string) (Codec, bool) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+
+	candidates := []string{mediaType}
+	if idx := strings.LastIndex(mediaType, "+"); idx >= 0 {
+		candidates = append(candidates, "application/"+mediaType[idx+1:])
+	}
+
+	for _, candidate := range candidates {
+		for _, c := range codecs {
+			if c.mediaType == candidate {
+				return c.codec, true
+			}
+		}
+	}
+	return nil, false
+}
+
+//mediaRange one element of Accept header
+type mediaRange struct {
+	mediaType string
+	params    map[string]string
+	q         float64
+}
+
+//specificity exact type rank before type/* which rank before */*
+func (m mediaRange) specificity() int {
+	switch {
+	case m.mediaType == "*/*":
+		return 0
+	case strings.HasSuffix(m.mediaType, "/*"):
+		return 1
+	}
+	return 2
+}
+
+func (m mediaRange) matches(mediaType string) bool {
+	if m.mediaType == "*/*" || m.mediaType == mediaType {
+		return true
+	}
+	return strings.HasSuffix(m.mediaType, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(m.mediaType, "*"))
+}
+
+//parseAccept parse Accept header ordered by preference, ranges with q=0 are dropped
+func parseAccept(accept string) []mediaRange {
+	ranges := make([]mediaRange, 0)
+	for _, value := range strings.Split(accept, ",") {
+		if strings.TrimSpace(value) == "" {
+			continue
+		}
+
+		mediaType, params, err := mime.ParseMediaType(value)
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			q, err = strconv.ParseFloat(v, 64)
+			if err != nil || q < 0 || q > 1 {
+				continue
+			}
+			delete(params, "q")
+		}
+		if q == 0 {
+			continue
+		}
+
+		ranges = append(ranges, mediaRange{mediaType, params, q})
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		if ranges[i].q != ranges[j].q {
+			return ranges[i].q > ranges[j].q
+		}
+		return ranges[i].specificity() > ranges[j].specificity()
+	})
+
+	return ranges
+}
+
+//acceptedCodec codec matching a media range of Accept with the parameters of that range
+type acceptedCodec struct {
+	codec  Codec
+	params map[string]string
+}
+
+type negotiationKey struct{}
+
+//Negotiate RequestFunc resolving Accept into the registered codecs, in order of preference,
+//before the endpoint runs. Decode refuse a request changing data when none is accepted
+func Negotiate(ctx context.Context, r *http.Request) context.Context {
+	return context.WithValue(ctx, negotiationKey{}, acceptedCodecs(ctx))
+}
+
+//acceptedCodecs codecs accepted by Accept in context, any media type is accepted when it is absent
+func acceptedCodecs(ctx context.Context) []acceptedCodec {
+	if accepted, ok := ctx.Value(negotiationKey{}).([]acceptedCodec); ok {
+		return accepted
+	}
+
+	accept, _ := ctx.Value(kithttp.ContextKeyRequestAccept).(string)
+	if strings.TrimSpace(accept) == "" {
+		accept = "*/*"
+	}
+
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+
+	accepted := make([]acceptedCodec, 0)
+	for _, r := range parseAccept(accept) {
+		for _, c := range codecs {
+			if r.matches(c.mediaType) {
+				accepted = append(accepted, acceptedCodec{c.codec, r.params})
+			}
+		}
+	}
+	return accepted
+}
+
+//safeMethod whether method of request in context does not change data
+func safeMethod(ctx context.Context) bool {
+	switch method, _ := ctx.Value(kithttp.ContextKeyRequestMethod).(string); method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+//negotiate pick the first accepted codec able to represent response. A request that changed
+//data is not answered 406 once the endpoint ran, its response is written with the default
+//codec instead, the only accepted codecs are specific to other kinds of response
+func negotiate(ctx context.Context, response interface{}) (Codec, map[string]string, error) {
+	accepted := acceptedCodecs(ctx)
+	for _, a := range accepted {
+		if a.codec.Supports(response) {
+			return a.codec, a.params, nil
+		}
+	}
+
+	if !safeMethod(ctx) {
+		codecsMu.RLock()
+		defer codecsMu.RUnlock()
+		return codecs[0].codec, nil, nil
+	}
+
+	return nil, nil, ErrNotAcceptable
+}
+
+//decodeBody decode request body with the codec of its Content-Type, JSON is assumed when it is absent
+func decodeBody(r *http.Request, model interface{}) error {
+	mediaType := "application/json"
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		var err error
+		mediaType, _, err = mime.ParseMediaType(contentType)
+		if err != nil {
+			return ErrUnsupportedMediaType.Wrap(err)
+		}
+	}
+
+	codec, ok := codecFor(mediaType)
+	if !ok {
+		return ErrUnsupportedMediaType.WithDetail("content_type", mediaType+" is not supported")
+	}
+
+	err := codec.Decode(r.Body, model)
+	if err == ErrDecodeUnsupported {
+		return ErrUnsupportedMediaType.WithDetail("content_type", mediaType+" request body is not supported")
+	}
+	if err != nil {
+		return ErrMalformedBody.Wrap(err).WithDetail("body", err.Error())
+	}
+
+	return nil
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) ContentType() string {
+	return "application/json; charset=utf-8"
+}
+
+func (jsonCodec) Supports(response interface{}) bool {
+	return true
+}
+
+func (jsonCodec) Encode(w io.Writer, response interface{}, params map[string]string) error {
+	return json.NewEncoder(w).Encode(response)
+}
+
+func (jsonCodec) Decode(r io.Reader, model interface{}) error {
+	return json.NewDecoder(r).Decode(model)
+}
+
+//xmlCodec write the JSON representation of response as XML, members become elements
+//and array items become <item> elements. Request bodies in the same form are read back
+//into their JSON representation, the model tells which text is a number or a boolean
+type xmlCodec struct{}
+
+func (xmlCodec) ContentType() string {
+	return "application/xml; charset=utf-8"
+}
+
+func (xmlCodec) Supports(response interface{}) bool {
+	return true
+}
+
+func (xmlCodec) Encode(w io.Writer, response interface{}, params map[string]string) error {
+	raw, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+
+	io.WriteString(w, xml.Header)
+	encoder := xml.NewEncoder(w)
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	if err = writeXML(encoder, decoder, "response"); err != nil {
+		return err
+	}
+	return encoder.Flush()
+}
+
+func (xmlCodec) Decode(r io.Reader, model interface{}) error {
+	root, err := readXML(xml.NewDecoder(r))
+	if err != nil {
+		return err
+	}
+
+	value, err := root.value(reflect.TypeOf(model))
+	if err != nil {
+		return err
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, model)
+}
+
+//maxXMLDepth nesting of elements accepted in request bodies
+const maxXMLDepth = 64
+
+//xmlNode element of request body, name is the key attribute of <entry> elements
+type xmlNode struct {
+	name     string
+	text     string
+	children []*xmlNode
+}
+
+//readXML read the root element of decoder
+func readXML(decoder *xml.Decoder) (*xmlNode, error) {
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return nil, err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return readXMLElement(decoder, start, 1)
+		}
+	}
+}
+
+func readXMLElement(decoder *xml.Decoder, start xml.StartElement, depth int) (*xmlNode, error) {
+	if depth > maxXMLDepth {
+		return nil, errors.New("xml: elements nested too deep")
+	}
+
+	node := &xmlNode{name: start.Name.Local}
+	if node.name == "entry" {
+		for _, attr := range start.Attr {
+			if attr.Name.Local == "key" {
+				node.name = attr.Value
+			}
+		}
+	}
+
+	var text strings.Builder
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			child, err := readXMLElement(decoder, t, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			node.children = append(node.children, child)
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			node.text = text.String()
+			return node, nil
+		}
+	}
+}
+
+//value JSON value of node for a field of type t, any type when t is nil
+func (n *xmlNode) value(t reflect.Type) (interface{}, error) {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	var kind reflect.Kind
+	if t != nil {
+		kind = t.Kind()
+	}
+
+	switch {
+	case len(n.children) == 0 && strings.TrimSpace(n.text) == "" &&
+		(kind == reflect.Struct || kind == reflect.Map || kind == reflect.Slice || kind == reflect.Array):
+		if kind == reflect.Struct || kind == reflect.Map {
+			return map[string]interface{}{}, nil
+		}
+		return []interface{}{}, nil
+	case len(n.children) == 0 || (kind == reflect.Slice && t.Elem().Kind() == reflect.Uint8):
+		return n.scalar(kind)
+	case kind == reflect.Slice || kind == reflect.Array:
+		return n.array(t.Elem())
+	case kind == reflect.Interface || t == nil:
+		for _, child := range n.children {
+			if child.name != "item" {
+				return n.object(func(string) reflect.Type { return nil })
+			}
+		}
+		return n.array(nil)
+	case kind == reflect.Map:
+		return n.object(func(string) reflect.Type { return t.Elem() })
+	case kind == reflect.Struct:
+		return n.object(func(name string) reflect.Type { return xmlFieldType(t, name) })
+	}
+
+	return nil, fmt.Errorf("xml: element %s can not be read into %s", n.name, t)
+}
+
+func (n *xmlNode) scalar(kind reflect.Kind) (interface{}, error) {
+	text := strings.TrimSpace(n.text)
+	switch kind {
+	case reflect.Bool:
+		b, err := strconv.ParseBool(text)
+		if err != nil {
+			return nil, fmt.Errorf("xml: element %s is not a boolean", n.name)
+		}
+		return b, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if _, err := strconv.ParseFloat(text, 64); err != nil {
+			return nil, fmt.Errorf("xml: element %s is not a number", n.name)
+		}
+		return json.Number(text), nil
+	}
+	return n.text, nil
+}
+
+func (n *xmlNode) array(elem reflect.Type) (interface{}, error) {
+	items := make([]interface{}, 0, len(n.children))
+	for _, child := range n.children {
+		item, err := child.value(elem)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (n *xmlNode) object(field func(name string) reflect.Type) (interface{}, error) {
+	members := make(map[string]interface{}, len(n.children))
+	for _, child := range n.children {
+		member, err := child.value(field(child.name))
+		if err != nil {
+			return nil, err
+		}
+		members[child.name] = member
+	}
+	return members, nil
+}
+
+//xmlFieldType type of the field of struct t encoding/json would decode member name into,
+//nil when there is none
+func xmlFieldType(t reflect.Type, name string) reflect.Type {
+	var folded reflect.Type
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := strings.Split(f.Tag.Get("json"), ",")[0]
+		if tag == "-" {
+			continue
+		}
+
+		if f.Anonymous && tag == "" {
+			embedded := f.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if ft := xmlFieldType(embedded, name); ft != nil {
+					return ft
+				}
+				continue
+			}
+		}
+
+		if f.PkgPath != "" {
+			continue
+		}
+		if tag == "" {
+			tag = f.Name
+		}
+		if tag == name {
+			return f.Type
+		}
+		if folded == nil && strings.EqualFold(tag, name) {
+			folded = f.Type
+		}
+	}
+	return folded
+}
+
+//writeXML write next JSON value of decoder as element name, null is omitted
+func writeXML(encoder *xml.Encoder, decoder *json.Decoder, name string) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token == nil {
+		return nil
+	}
+
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	if !validXMLName(name) {
+		start = xml.StartElement{
+			Name: xml.Name{Local: "entry"},
+			Attr: []xml.Attr{{Name: xml.Name{Local: "key"}, Value: name}},
+		}
+	}
+	if err = encoder.EncodeToken(start); err != nil {
+		return err
+	}
+
+	switch v := token.(type) {
+	case json.Delim:
+		for decoder.More() {
+			child := "item"
+			if v == '{' {
+				key, err := decoder.Token()
+				if err != nil {
+					return err
+				}
+				child = key.(string)
+			}
+			if err = writeXML(encoder, decoder, child); err != nil {
+				return err
+			}
+		}
+		// closing delimiter
+		if _, err = decoder.Token(); err != nil {
+			return err
+		}
+	case string:
+		err = encoder.EncodeToken(xml.CharData(v))
+	case json.Number:
+		err = encoder.EncodeToken(xml.CharData(v.String()))
+	case bool:
+		err = encoder.EncodeToken(xml.CharData(strconv.FormatBool(v)))
+	}
+	if err != nil {
+		return err
+	}
+
+	return encoder.EncodeToken(start.End())
+}
+
+func validXMLName(name string) bool {
+	if name == "" || strings.HasPrefix(strings.ToLower(name), "xml") {
+		return false
+	}
+	for i, r := range name {
+		letter := r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+		if !letter && (i == 0 || !(r == '-' || r == '.' || (r >= '0' && r <= '9'))) {
+			return false
+		}
+	}
+	return true
+}
+
+//csvCodec write list responses implementing CSVMarshaler. Request bodies are not
+//supported, imports stream their body instead
+type csvCodec struct{}
+
+func (csvCodec) ContentType() string {
+	return "text/csv; charset=utf-8"
+}
+
+func (csvCodec) Supports(response interface{}) bool {
+	_, ok := response.(CSVMarshaler)
+	return ok
+}
+
+func (csvCodec) Encode(w io.Writer, response interface{}, params map[string]string) error {
+	records, err := response.(CSVMarshaler).MarshalCSV()
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(w)
+	if err = writer.WriteAll(records); err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+func (csvCodec) Decode(r io.Reader, model interface{}) error {
+	return ErrDecodeUnsupported
+}
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) ContentType() string {
+	return msgpack.MediaType
+}
+
+func (msgpackCodec) Supports(response interface{}) bool {
+	return true
+}
+
+func (msgpackCodec) Encode(w io.Writer, response interface{}, params map[string]string) error {
+	return msgpack.NewEncoder(w).Encode(response)
+}
+
+func (msgpackCodec) Decode(r io.Reader, model interface{}) error {
+	return msgpack.NewDecoder(r).Decode(model)
+}
+
+//vcardCodec write responses implementing VCardMarshaler, the version parameter
+//of the accepted media range select the vCard version
+type vcardCodec struct{}
+
+func (vcardCodec) ContentType() string {
+	return vcard.MediaType + "; charset=utf-8"
+}
+
+func (vcardCodec) Supports(response interface{}) bool {
+	_, ok := response.(VCardMarshaler)
+	return ok
+}
+
+func (vcardCodec) Encode(w io.Writer, response interface{}, params map[string]string) error {
+	body, err := response.(VCardMarshaler).MarshalVCard(params["version"])
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(body)
+	return err
+}
+
+func (vcardCodec) Decode(r io.Reader, model interface{}) error {
+	return ErrDecodeUnsupported
+}
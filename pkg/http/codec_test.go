@@ -0,0 +1,260 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	kithttp "github.com/go-kit/kit/transport/http"
+
+	"phonebook/pkg/httperror"
+	"phonebook/pkg/msgpack"
+)
+
+type contact struct {
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+type csvContacts []contact
+
+func (c csvContacts) MarshalCSV() ([][]string, error) {
+	records := [][]string{{"name", "age"}}
+	for _, item := range c {
+		records = append(records, []string{item.Name, fmt.Sprint(item.Age)})
+	}
+	return records, nil
+}
+
+type vcardContact contact
+
+func (c vcardContact) MarshalVCard(version string) ([]byte, error) {
+	return []byte("BEGIN:VCARD\r\nVERSION:" + version + "\r\nEND:VCARD\r\n"), nil
+}
+
+// negotiatedContext context of request as prepared by the server before Decode
+func negotiatedContext(method string, accept string) context.Context {
+	r := httptest.NewRequest(method, "/", nil)
+	if accept != "" {
+		r.Header.Set("Accept", accept)
+	}
+	ctx := kithttp.PopulateRequestContext(context.Background(), r)
+	return Negotiate(ctx, r)
+}
+
+func contentTypes(accepted []acceptedCodec) []string {
+	types := make([]string, 0, len(accepted))
+	for _, a := range accepted {
+		types = append(types, a.codec.ContentType())
+	}
+	return types
+}
+
+func TestParseAccept(t *testing.T) {
+	tests := []struct {
+		name   string
+		accept string
+		want   []string
+	}{
+		{"empty", "", []string{}},
+		{"q value ordering", "text/html;q=0.5, application/json, text/csv;q=0.8", []string{"application/json;1", "text/csv;0.8", "text/html;0.5"}},
+		{"specificity of equal q", "*/*, text/*, text/csv", []string{"text/csv;1", "text/*;1", "*/*;1"}},
+		{"q before specificity", "text/csv;q=0.2, */*;q=0.9", []string{"*/*;0.9", "text/csv;0.2"}},
+		{"order kept for equal rank", "application/xml, application/json", []string{"application/xml;1", "application/json;1"}},
+		{"q=0 dropped", "application/json;q=0, text/csv", []string{"text/csv;1"}},
+		{"invalid ranges skipped", "text/, application/xml;q=2, application/json;q=abc, text/csv", []string{"text/csv;1"}},
+		{"case insensitive", "Application/JSON", []string{"application/json;1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make([]string, 0)
+			for _, r := range parseAccept(tt.accept) {
+				got = append(got, fmt.Sprintf("%s;%v", r.mediaType, r.q))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAcceptParams(t *testing.T) {
+	ranges := parseAccept("text/vcard;version=3.0;q=0.5")
+	if len(ranges) != 1 {
+		t.Fatalf("got %d ranges, want 1", len(ranges))
+	}
+	want := map[string]string{"version": "3.0"}
+	if !reflect.DeepEqual(ranges[0].params, want) {
+		t.Errorf("got params %v, want %v", ranges[0].params, want)
+	}
+}
+
+func TestAcceptedCodecs(t *testing.T) {
+	const (
+		jsonType    = "application/json; charset=utf-8"
+		xmlType     = "application/xml; charset=utf-8"
+		csvType     = "text/csv; charset=utf-8"
+		vcardType   = "text/vcard; charset=utf-8"
+		msgpackType = "application/msgpack"
+	)
+
+	tests := []struct {
+		name   string
+		accept string
+		want   []string
+	}{
+		{"exact", "application/msgpack", []string{msgpackType}},
+		{"ordered by q", "application/msgpack;q=0.5, text/csv", []string{csvType, msgpackType}},
+		{"type wildcard", "text/*", []string{xmlType, csvType, vcardType, vcardType}},
+		{"exact before wildcard", "text/csv, application/*;q=0.1", []string{csvType, jsonType, jsonType, xmlType, msgpackType, msgpackType}},
+		{"nothing registered", "image/png", []string{}},
+		{"only refused", "application/json;q=0", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := contentTypes(acceptedCodecs(negotiatedContext(http.MethodGet, tt.accept)))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAcceptedCodecsWithoutAccept(t *testing.T) {
+	for _, ctx := range []context.Context{context.Background(), negotiatedContext(http.MethodGet, "")} {
+		got := acceptedCodecs(ctx)
+		if len(got) != len(codecs) {
+			t.Fatalf("got %d codecs, want every registered codec", len(got))
+		}
+		if got[0].codec.ContentType() != codecs[0].codec.ContentType() {
+			t.Errorf("got %s first, want the default codec", got[0].codec.ContentType())
+		}
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		accept      string
+		response    interface{}
+		contentType string
+		params      map[string]string
+		err         error
+	}{
+		{"any", http.MethodGet, "", contact{}, "application/json; charset=utf-8", nil, nil},
+		{"codec of response", http.MethodGet, "text/csv", csvContacts{}, "text/csv; charset=utf-8", nil, nil},
+		{"unsupported range skipped", http.MethodGet, "text/csv, application/xml;q=0.5", contact{}, "application/xml; charset=utf-8", nil, nil},
+		{"wildcard skip unsupported codecs", http.MethodGet, "text/*", contact{}, "application/xml; charset=utf-8", nil, nil},
+		{"params of range", http.MethodGet, "text/vcard;version=3.0", vcardContact{}, "text/vcard; charset=utf-8", map[string]string{"version": "3.0"}, nil},
+		{"not acceptable", http.MethodGet, "text/csv", contact{}, "", nil, ErrNotAcceptable},
+		{"nothing registered", http.MethodHead, "image/png", contact{}, "", nil, ErrNotAcceptable},
+		{"default codec after change", http.MethodPost, "text/csv", contact{}, "application/json; charset=utf-8", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codec, params, err := negotiate(negotiatedContext(tt.method, tt.accept), tt.response)
+			if err != tt.err {
+				t.Fatalf("got error %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				if status := err.(*httperror.Error).StatusCode(); status != http.StatusNotAcceptable {
+					t.Errorf("got status %d, want %d", status, http.StatusNotAcceptable)
+				}
+				return
+			}
+			if codec.ContentType() != tt.contentType {
+				t.Errorf("got %s, want %s", codec.ContentType(), tt.contentType)
+			}
+			if len(params) != len(tt.params) || (len(params) != 0 && !reflect.DeepEqual(params, tt.params)) {
+				t.Errorf("got params %v, want %v", params, tt.params)
+			}
+		})
+	}
+}
+
+func TestDecodeNotAcceptable(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		accept string
+		err    error
+	}{
+		{"change refused before the endpoint", http.MethodPost, "image/png", ErrNotAcceptable},
+		{"change accepting a codec", http.MethodPost, "text/csv", nil},
+		{"read left to encode", http.MethodGet, "image/png", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/", nil)
+			_, err := Decode(nil)(negotiatedContext(tt.method, tt.accept), r)
+			if err != tt.err {
+				t.Errorf("got error %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestDecodeBody(t *testing.T) {
+	packed, err := msgpack.Marshal(contact{Name: "ana", Age: 30})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		err         error
+		status      int
+		cause       error
+	}{
+		{"json assumed", "", []byte(`{"name":"ana","age":30}`), nil, 0, nil},
+		{"json with charset", "application/json; charset=utf-8", []byte(`{"name":"ana","age":30}`), nil, 0, nil},
+		{"structured syntax suffix", "application/vnd.phonebook+json", []byte(`{"name":"ana","age":30}`), nil, 0, nil},
+		{"xml", "application/xml", []byte(`<request><name>ana</name><age>30</age></request>`), nil, 0, nil},
+		{"msgpack", msgpack.MediaType, packed, nil, 0, nil},
+		{"unknown media type", "image/png", []byte("png"), ErrUnsupportedMediaType, http.StatusUnsupportedMediaType, nil},
+		{"response only codec", "text/csv", []byte("name,age\nana,30\n"), ErrUnsupportedMediaType, http.StatusUnsupportedMediaType, nil},
+		{"invalid content type", "text/", []byte(`{}`), ErrUnsupportedMediaType, http.StatusUnsupportedMediaType, nil},
+		{"malformed json", "application/json", []byte(`{"name":`), ErrMalformedBody, http.StatusUnprocessableEntity, nil},
+		{"malformed xml", "application/xml", []byte(`<request><age>thirty</age></request>`), ErrMalformedBody, http.StatusUnprocessableEntity, nil},
+		{"msgpack too large", msgpack.MediaType, []byte{0xdb, 0xff, 0xff, 0xff, 0xff}, ErrMalformedBody, http.StatusUnprocessableEntity, msgpack.ErrTooLarge},
+		{"msgpack too deep", msgpack.MediaType, append(bytes.Repeat([]byte{0x91}, msgpack.MaxDepth+1), 0xc0), ErrMalformedBody, http.StatusUnprocessableEntity, msgpack.ErrTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(tt.body))
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+
+			var got contact
+			err := decodeBody(r, &got)
+			if tt.err == nil {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if want := (contact{Name: "ana", Age: 30}); got != want {
+					t.Errorf("got %+v, want %+v", got, want)
+				}
+				return
+			}
+
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got error %v, want %v", err, tt.err)
+			}
+			if status := err.(*httperror.Error).StatusCode(); status != tt.status {
+				t.Errorf("got status %d, want %d", status, tt.status)
+			}
+			if tt.cause != nil && !errors.Is(err, tt.cause) {
+				t.Errorf("got error %v, want cause %v", err, tt.cause)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package http
+
+import (
+	"net/http"
+	"strings"
+)
+
+// MatchETag report whether etag is listed in If-Match / If-None-Match header value,
+// weak comparison ignore the W/ prefix as required by If-None-Match
+func MatchETag(header string, etag string, weak bool) bool {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return false
+	}
+
+	if header == "*" {
+		return true
+	}
+
+	if weak {
+		etag = strings.TrimPrefix(etag, "W/")
+	} else if strings.HasPrefix(etag, "W/") {
+		return false
+	}
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if weak {
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NotModified response of conditional GET whose If-None-Match matched
+type NotModified struct {
+	ETag string
+}
+
+// StatusCode ...
+func (n NotModified) StatusCode() int {
+	return http.StatusNotModified
+}
+
+// Headers ...
+func (n NotModified) Headers() http.Header {
+	return http.Header{
+		"Etag": []string{n.ETag},
+	}
+}
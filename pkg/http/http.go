@@ -3,11 +3,15 @@ package http
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
 	"reflect"
 	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi"
+	kithttp "github.com/go-kit/kit/transport/http"
 	"github.com/google/uuid"
 	"github.com/iancoleman/strcase"
 
@@ -25,9 +29,14 @@ type DecodeParam struct {
 	Options []DecodeOptions
 }
 
-//Decode generate a decode function to decode request body (json) to model
+//Decode generate a decode function to decode request body to model with the codec of its Content-Type
 func Decode(model interface{}) func(context.Context, *http.Request) (request interface{}, err error) {
 	return func(ctx context.Context, r *http.Request) (interface{}, error) {
+		// refused before the endpoint runs, a 406 after it would report a change that was made
+		if !safeMethod(ctx) && len(acceptedCodecs(ctx)) == 0 {
+			return nil, ErrNotAcceptable
+		}
+
 		if model == nil {
 			return nil, nil
 		}
@@ -52,17 +61,11 @@ func Decode(model interface{}) func(context.Context, *http.Request) (request int
 			_model, _ = common.DeepCopy(model)
 		}
 
-		if r.ContentLength != 0 {
-			contentType := r.Header["Content-Type"]
-			if common.StringInSlice("application/json", contentType) {
-				_model, err = ParseJSON(ctx, r, _model)
-				if err != nil {
-					httperr := &httperror.ErrorWithStatusCode{
-						Err:        err.Error(),
-						StatusCode: http.StatusUnprocessableEntity,
-					}
-					return nil, httperr
-				}
+		// body kept by GetBody is read by the endpoint itself
+		if _, streamed := r.Body.(streamedBody); r.ContentLength != 0 && !streamed {
+			err = decodeBody(r, _model)
+			if err != nil {
+				return nil, err
 			}
 		}
 
@@ -95,11 +98,7 @@ func Decode(model interface{}) func(context.Context, *http.Request) (request int
 
 		err = validator.DefaultValidator()(_model)
 		if err != nil {
-			httperr := &httperror.ErrorWithStatusCode{
-				Err:        err.Error(),
-				StatusCode: http.StatusUnprocessableEntity,
-			}
-			return nil, httperr
+			return nil, ValidationError(err)
 		}
 
 		return _model, nil
@@ -173,11 +172,7 @@ func DecodeJSON(model interface{}) func(context.Context, *http.Request) (request
 
 		err = validator.DefaultValidator()(_model)
 		if err != nil {
-			httperr := &httperror.ErrorWithStatusCode{
-				Err:        err.Error(),
-				StatusCode: http.StatusUnprocessableEntity,
-			}
-			return nil, httperr
+			return nil, ValidationError(err)
 		}
 
 		return _model, nil
@@ -203,6 +198,25 @@ func GetURLParam(params []string) DecodeOptions {
 	}
 }
 
+//GetBody built-in DecodeOptions keeping request body in io.Reader field, so the endpoint can stream it
+func GetBody(field string) DecodeOptions {
+	return func(ctx context.Context, model interface{}, r *http.Request) error {
+		val := reflect.ValueOf(model).Elem().FieldByName(field)
+		if !val.IsValid() || !val.CanSet() {
+			return fmt.Errorf("field %s is not found", field)
+		}
+
+		r.Body = streamedBody{r.Body}
+		val.Set(reflect.ValueOf(r.Body))
+		return nil
+	}
+}
+
+//streamedBody request body kept by GetBody
+type streamedBody struct {
+	io.ReadCloser
+}
+
 func getURLParamUsingTag(ctx context.Context, model interface{}, r *http.Request) error {
 	var err error
 	typ := reflect.TypeOf(model).Elem()
@@ -245,12 +259,44 @@ func GetQueryUsingTag(ctx context.Context, model interface{}, r *http.Request) e
 }
 
 func getQuery(ctx context.Context, model interface{}, r *http.Request, query string, valIdx int) error {
-	value := r.URL.Query().Get(query)
-	if value == "" {
+	values := r.URL.Query()[query]
+	if len(values) == 0 {
 		return nil
 	}
 
-	return fillFieldValue(model, value, valIdx)
+	//slice field take every occurrence of the param, each of them may hold comma separated values
+	field := reflect.ValueOf(model).Elem().Field(valIdx)
+	if field.Kind() == reflect.Slice {
+		return setSliceValue(field, values)
+	}
+
+	if values[0] == "" {
+		return nil
+	}
+
+	return fillFieldValue(model, values[0], valIdx)
+}
+
+func setSliceValue(field reflect.Value, values []string) error {
+	slice := reflect.MakeSlice(field.Type(), 0, len(values))
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			item = strings.TrimSpace(item)
+			if item == "" {
+				continue
+			}
+
+			elem := reflect.New(field.Type().Elem()).Elem()
+			err := setFieldValue(elem, item)
+			if err != nil {
+				return err
+			}
+			slice = reflect.Append(slice, elem)
+		}
+	}
+
+	field.Set(slice)
+	return nil
 }
 
 //GetHeaderUsingTag ...
@@ -281,33 +327,48 @@ func getHeader(ctx context.Context, model interface{}, r *http.Request, header s
 func fillFieldValue(model interface{}, value string, valIdx int) error {
 	val := reflect.ValueOf(model).Elem()
 
-	switch valtype := val.Field(valIdx).Type().String(); valtype {
+	return setFieldValue(val.Field(valIdx), value)
+}
+
+func setFieldValue(field reflect.Value, value string) error {
+	// pointer field is allocated so optional params stay nil when absent
+	if field.Kind() == reflect.Ptr {
+		v := reflect.New(field.Type().Elem())
+		err := setFieldValue(v.Elem(), value)
+		if err != nil {
+			return err
+		}
+		field.Set(v)
+		return nil
+	}
+
+	switch valtype := field.Type().String(); valtype {
 	case "string":
-		val.Field(valIdx).SetString(value)
+		field.SetString(value)
 	case "int":
 		v, err := strconv.ParseInt(value, 10, 64)
 		if err != nil {
 			return err
 		}
-		val.Field(valIdx).SetInt(v)
+		field.SetInt(v)
 	case "int64":
 		v, err := strconv.ParseInt(value, 10, 64)
 		if err != nil {
 			return err
 		}
-		val.Field(valIdx).SetInt(v)
+		field.SetInt(v)
 	case "bool":
 		v, err := strconv.ParseBool(value)
 		if err != nil {
 			return err
 		}
-		val.Field(valIdx).SetBool(v)
+		field.SetBool(v)
 	case "uuid.UUID":
 		v, err := uuid.Parse(value)
 		if err != nil {
 			return err
 		}
-		val.Field(valIdx).Set(reflect.ValueOf(v))
+		field.Set(reflect.ValueOf(v))
 	}
 
 	return nil
@@ -317,7 +378,35 @@ type err interface {
 	error() error
 }
 
-//Encode generate a encode function to encode response to json
+//StatusCoder response with its own http status code
+type StatusCoder interface {
+	StatusCode() int
+}
+
+//Headerer response with its own http headers
+type Headerer interface {
+	Headers() http.Header
+}
+
+//ForceAccept RequestFunc replacing Accept of request by mediaType, for routes whose path fix the representation.
+//The version query param is kept as media type parameter
+func ForceAccept(mediaType string) kithttp.RequestFunc {
+	return func(ctx context.Context, r *http.Request) context.Context {
+		accept := mediaType
+		if version := r.URL.Query().Get("version"); version != "" {
+			accept += ";version=" + version
+		}
+		return context.WithValue(ctx, kithttp.ContextKeyRequestAccept, accept)
+	}
+}
+
+//Streamer response writing its own body, e.g. a file, no codec is negotiated for it
+type Streamer interface {
+	ContentType() string
+	WriteTo(w io.Writer) (int64, error)
+}
+
+//Encode generate a encode function to encode response with the codec negotiated from Accept
 func Encode() func(ctx context.Context, w http.ResponseWriter, response interface{}) error {
 	return func(ctx context.Context, w http.ResponseWriter, response interface{}) error {
 		if e, ok := response.(err); ok && e.error() != nil {
@@ -331,9 +420,59 @@ func Encode() func(ctx context.Context, w http.ResponseWriter, response interfac
 			return nil
 		}
 
-		w.Header().Set("Content-Type", "application/json; charset=utf-8")
-		json.NewEncoder(w).Encode(response)
-		return nil
+		code := http.StatusOK
+		if sc, ok := response.(StatusCoder); ok {
+			code = sc.StatusCode()
+		}
+
+		// negotiate before any header is written so 406 does not carry headers of response
+		var codec Codec
+		var params map[string]string
+		streamer, streamed := response.(Streamer)
+		if code != http.StatusNotModified && !streamed {
+			var negotiateErr error
+			codec, params, negotiateErr = negotiate(ctx, response)
+			if negotiateErr != nil {
+				return negotiateErr
+			}
+		}
+
+		if h, ok := response.(Headerer); ok {
+			for key, values := range h.Headers() {
+				for _, value := range values {
+					w.Header().Add(key, value)
+				}
+			}
+		}
+
+		// 304 must not carry a body
+		if code == http.StatusNotModified {
+			w.WriteHeader(code)
+			return nil
+		}
+
+		if streamed {
+			w.Header().Set("Content-Type", streamer.ContentType())
+			w.WriteHeader(code)
+			_, err := streamer.WriteTo(w)
+			return err
+		}
+
+		w.Header().Set("Content-Type", codec.ContentType())
+		w.WriteHeader(code)
+		return codec.Encode(w, response, params)
+	}
+}
+
+//ValidationError convert error of validator into httperror with field details
+func ValidationError(err error) error {
+	if details, ok := validator.Translate(err); ok {
+		return httperror.ErrValidation.Wrap(err).WithDetails(details)
+	}
+
+	return &httperror.ErrorWithStatusCode{
+		Err:        err.Error(),
+		StatusCode: http.StatusUnprocessableEntity,
 	}
 }
 
@@ -4,12 +4,62 @@ import (
 	// internal golang package
 	"context"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"net/http"
+	"strings"
 )
 
-// Kind ...
-type Kind = int
+// Kind classify error, each kind is mapped to one http status code
+type Kind int
+
+const (
+	// Internal unexpected failure, mapped to 500
+	Internal Kind = iota
+	// NotFound requested resource does not exist, mapped to 404
+	NotFound
+	// Conflict request conflict with current state of resource, mapped to 409
+	Conflict
+	// Invalid request is well formed but semantically wrong, mapped to 422
+	Invalid
+	// Unauthorized caller is not authenticated, mapped to 401
+	Unauthorized
+	// Forbidden caller is authenticated but not allowed, mapped to 403
+	Forbidden
+	// PreconditionFailed conditional request header does not match, mapped to 412
+	PreconditionFailed
+	// UnsupportedMediaType request body format is not supported, mapped to 415
+	UnsupportedMediaType
+	// NotAcceptable response can not be represented in any accepted format, mapped to 406
+	NotAcceptable
+)
+
+var statusCodes = map[Kind]int{
+	Internal:             http.StatusInternalServerError,
+	NotFound:             http.StatusNotFound,
+	Conflict:             http.StatusConflict,
+	Invalid:              http.StatusUnprocessableEntity,
+	Unauthorized:         http.StatusUnauthorized,
+	Forbidden:            http.StatusForbidden,
+	PreconditionFailed:   http.StatusPreconditionFailed,
+	UnsupportedMediaType: http.StatusUnsupportedMediaType,
+	NotAcceptable:        http.StatusNotAcceptable,
+}
+
+// StatusCode http status code of kind
+func (k Kind) StatusCode() int {
+	if code, ok := statusCodes[k]; ok {
+		return code
+	}
+	return http.StatusInternalServerError
+}
+
+// generic error catalogue, domain packages declare their own entries with New
+var (
+	ErrInternal     = New(Internal, "internal_error", "internal server error")
+	ErrValidation   = New(Invalid, "validation_failed", "request is not valid")
+	ErrUnauthorized = New(Unauthorized, "unauthorized", "authentication is required")
+	ErrForbidden    = New(Forbidden, "forbidden", "permission is required")
+)
 
 // ErrorWithStatusCode error with http status code
 type ErrorWithStatusCode struct {
@@ -21,32 +71,154 @@ func (e *ErrorWithStatusCode) Error() string {
 	return e.Err
 }
 
-// Error encapsulate error with type of error
+// Error encapsulate error with type of error, machine readable code,
+// human message and optional field details
 type Error struct {
 	err     string
+	cause   error
 	kind    Kind
+	code    string
 	message string
+	details map[string]string
 }
 
-func New(err error, kind Kind, message string) *Error {
+// New create catalogue error with kind, code and message
+func New(kind Kind, code string, message string) *Error {
 	return &Error{
-		err:     fmt.Sprintf("%v", err),
+		err:     code,
 		kind:    kind,
+		code:    code,
 		message: message,
 	}
 }
 
+// Wrap create copy of e keeping err as underlying cause
+func (e *Error) Wrap(err error) *Error {
+	c := e.clone()
+	if nil != err {
+		c.err = err.Error()
+		c.cause = err
+	}
+	return c
+}
+
+// WithDetail create copy of e with additional field detail
+func (e *Error) WithDetail(field string, detail string) *Error {
+	c := e.clone()
+	c.details[field] = detail
+	return c
+}
+
+// WithDetails create copy of e with additional field details
+func (e *Error) WithDetails(details map[string]string) *Error {
+	c := e.clone()
+	for field, detail := range details {
+		c.details[field] = detail
+	}
+	return c
+}
+
+func (e *Error) clone() *Error {
+	c := *e
+	c.details = make(map[string]string, len(e.details))
+	for field, detail := range e.details {
+		c.details[field] = detail
+	}
+	return &c
+}
+
+func (e *Error) Error() string {
+	return e.err
+}
+
+// Unwrap cause given to Wrap, nil for catalogue entries
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Kind ...
+func (e *Error) Kind() Kind {
+	return e.kind
+}
+
+// Code ...
+func (e *Error) Code() string {
+	return e.code
+}
+
+// Message ...
+func (e *Error) Message() string {
+	return e.message
+}
+
+// Details ...
+func (e *Error) Details() map[string]string {
+	return e.details
+}
+
+// StatusCode http status code of error, implements kithttp.StatusCoder
+func (e *Error) StatusCode() int {
+	return e.kind.StatusCode()
+}
+
+// Is report whether target is the same catalogue entry as e
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.kind == e.kind && t.code == e.code
+}
+
+// Response json envelope of error response
+type Response struct {
+	Error ResponseBody `json:"error"`
+}
+
+// ResponseBody ...
+type ResponseBody struct {
+	Code    string            `json:"code"`
+	Message string            `json:"message"`
+	Details map[string]string `json:"details,omitempty"`
+}
+
 // EncodeError ...
 func EncodeError(_ context.Context, err error, w http.ResponseWriter) {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 
-	code := http.StatusInternalServerError
-	if sc, ok := err.(*ErrorWithStatusCode); ok {
-		code = sc.StatusCode
+	code := ErrInternal.StatusCode()
+	body := ResponseBody{
+		Code:    ErrInternal.code,
+		Message: ErrInternal.message,
+	}
+
+	// errors wrapped by the caller are reported as the catalogue entry they wrap
+	var catalogued *Error
+	var withStatus *ErrorWithStatusCode
+	switch {
+	case errors.As(err, &catalogued):
+		code = catalogued.StatusCode()
+		body = ResponseBody{
+			Code:    catalogued.code,
+			Message: catalogued.message,
+			Details: catalogued.details,
+		}
+	case errors.As(err, &withStatus):
+		code = withStatus.StatusCode
+		body = ResponseBody{
+			Code:    statusCode(code),
+			Message: withStatus.Err,
+		}
 	}
 
 	w.WriteHeader(code)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"error": err.Error(),
+	json.NewEncoder(w).Encode(Response{
+		Error: body,
 	})
 }
+
+// statusCode machine readable code from http status, e.g. unprocessable_entity
+func statusCode(code int) string {
+	text := strings.ToLower(http.StatusText(code))
+	if text == "" {
+		return "error"
+	}
+	return strings.Replace(text, " ", "_", -1)
+}
@@ -3,6 +3,7 @@ package logger
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"regexp"
 	"time"
 
@@ -13,6 +14,7 @@ import (
 	"io"
 	"runtime"
 	"strconv"
+	"sync/atomic"
 
 	"github.com/go-kit/kit/log/level"
 )
@@ -44,6 +46,22 @@ type KitxLogger struct {
 	stdout log.Logger
 	level  level.Value
 	filter level.Option
+	// threshold is shared by copies of the logger so SetLevel apply to all of them
+	threshold *int32
+}
+
+//levels severity of every level name, entries below the threshold are dropped
+var levels = map[string]int32{"debug": 0, "info": 1, "warn": 2, "error": 3}
+
+//SetLevel change minimum level logged, one of debug, info, warn or error
+func (logger KitxLogger) SetLevel(name string) error {
+	severity, ok := levels[name]
+	if !ok {
+		return fmt.Errorf("unknown log level %q", name)
+	}
+
+	atomic.StoreInt32(logger.threshold, severity)
+	return nil
 }
 
 func (logger KitxLogger) SetCaller(caller log.Valuer) KitxLogger {
@@ -55,9 +73,11 @@ func NewKitxLogger(errorWriter io.Writer, outWriter io.Writer) KitxLogger {
 	logger := KitxLogger{
 		stderr: log.NewLogfmtLogger(errorWriter),
 		stdout: log.NewLogfmtLogger(outWriter),
-		level:  level.InfoValue(),
-		caller: CallerRegex("canfazz"),
+		level:     level.InfoValue(),
+		caller:    CallerRegex("canfazz"),
+		threshold: new(int32),
 	}
+	*logger.threshold = levels["info"]
 	logger.stderr = log.With(logger.stderr, "ts", log.DefaultTimestampUTC)
 	logger.stdout = log.With(logger.stdout, "ts", log.DefaultTimestampUTC)
 	return logger
@@ -178,6 +198,10 @@ func (logger KitxLogger) Log(keyvals ...interface{}) error {
 
 	var currentLogger log.Logger
 	err := logger.getValByKey("err", keyvals...)
+	if !logger.enabled(err, logger.getValByKey("level", keyvals...)) {
+		return nil
+	}
+
 	if err != nil {
 		currentLogger = level.NewInjector(logger.stderr, logger.level)
 		keyvals = append(keyvals, "level", "error")
@@ -188,6 +212,21 @@ func (logger KitxLogger) Log(keyvals ...interface{}) error {
 	return currentLogger.Log(keyvals...)
 }
 
+//enabled tell whether entry pass the threshold, entries with err are errors and
+//entries without level are info
+func (logger KitxLogger) enabled(err interface{}, value interface{}) bool {
+	severity := levels["info"]
+	if err != nil {
+		severity = levels["error"]
+	} else if nil != value {
+		if known, ok := levels[fmt.Sprint(value)]; ok {
+			severity = known
+		}
+	}
+
+	return severity >= atomic.LoadInt32(logger.threshold)
+}
+
 func (logger KitxLogger) getValByKey(searchKey string, keyvals ...interface{}) interface{} {
 	for i := 0; i < len(keyvals); i += 2 {
 		key, ok := keyvals[i].(string)
@@ -3,9 +3,10 @@ package middleware
 import (
 	"context"
 	"fmt"
-	"net/http"
 	"sync"
 
+	"phonebook/pkg/auth"
+	httpserver "phonebook/pkg/http"
 	"phonebook/pkg/httperror"
 	"phonebook/pkg/logger"
 	"phonebook/pkg/validator"
@@ -60,10 +61,35 @@ func Validator() endpoint.Middleware {
 		return func(ctx context.Context, request interface{}) (response interface{}, err error) {
 			err = validator.DefaultValidator()(request)
 			if err != nil {
-				return nil, &httperror.ErrorWithStatusCode{
-					Err:        err.Error(),
-					StatusCode: http.StatusUnprocessableEntity,
-				}
+				return nil, httpserver.ValidationError(err)
+			}
+			return f(ctx, request)
+		}
+	}
+}
+
+//Authorizer report whether subject is granted permission
+type Authorizer interface {
+	HasPermission(ctx context.Context, subject string, permission string) (bool, error)
+}
+
+//Authorize wrap endpoint function to require permission from principal in context
+//Request without principal is answered with 401, principal without permission with 403
+func Authorize(authorizer Authorizer, permission string) endpoint.Middleware {
+	return func(f endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+			principal, ok := auth.FromContext(ctx)
+			if !ok || nil == principal {
+				return nil, httperror.ErrUnauthorized
+			}
+
+			granted, err := authorizer.HasPermission(ctx, principal.ID, permission)
+			if err != nil {
+				return nil, err
+			}
+
+			if !granted {
+				return nil, httperror.ErrForbidden.WithDetail("permission", permission)
 			}
 			return f(ctx, request)
 		}
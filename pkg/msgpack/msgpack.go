@@ -0,0 +1,349 @@
+package msgpack
+
+import (
+	// internal golang package
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// MediaType of MessagePack
+const MediaType = "application/msgpack"
+
+// ErrUnsupported value has an extension type that has no JSON representation
+var ErrUnsupported = errors.New("unsupported msgpack type")
+
+// ErrTooLarge value is longer than MaxLength or nested deeper than MaxDepth
+var ErrTooLarge = errors.New("msgpack value is too large")
+
+const (
+	// MaxLength longest string, binary, array or map accepted by Decoder
+	MaxLength = 1 << 20
+	// MaxDepth deepest nesting of arrays and maps accepted by Decoder
+	MaxDepth = 64
+	// maxPrealloc elements reserved up front, the rest grow with the data actually read
+	// so a declared length can not allocate more than the input holds
+	maxPrealloc = 64
+)
+
+// Marshal encode v as MessagePack. v is converted through encoding/json first so
+// field names, omitempty and custom json marshalers behave exactly like JSON responses
+func Marshal(v interface{}) ([]byte, error) {
+	var buffer bytes.Buffer
+	if err := NewEncoder(&buffer).Encode(v); nil != err {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// Unmarshal decode MessagePack data into v through encoding/json
+func Unmarshal(data []byte, v interface{}) error {
+	return NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// Encoder write MessagePack values
+type Encoder struct {
+	w *bufio.Writer
+}
+
+// NewEncoder ...
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: bufio.NewWriter(w)}
+}
+
+// Encode write v as one MessagePack value
+func (e *Encoder) Encode(v interface{}) error {
+	raw, err := json.Marshal(v)
+	if nil != err {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	var generic interface{}
+	if err = decoder.Decode(&generic); nil != err {
+		return err
+	}
+
+	if err = e.encode(generic); nil != err {
+		return err
+	}
+	return e.w.Flush()
+}
+
+func (e *Encoder) encode(v interface{}) error {
+	switch value := v.(type) {
+	case nil:
+		return e.w.WriteByte(0xc0)
+	case bool:
+		if value {
+			return e.w.WriteByte(0xc3)
+		}
+		return e.w.WriteByte(0xc2)
+	case json.Number:
+		if i, err := value.Int64(); nil == err {
+			return e.writeInt(i)
+		}
+		f, err := value.Float64()
+		if nil != err {
+			return err
+		}
+		e.w.WriteByte(0xcb)
+		return e.writeUint(math.Float64bits(f), 8)
+	case string:
+		e.writeHeader(len(value), 0xa0, 31, 0xd9, 0xda, 0xdb)
+		_, err := e.w.WriteString(value)
+		return err
+	case []interface{}:
+		e.writeHeader(len(value), 0x90, 15, 0, 0xdc, 0xdd)
+		for _, item := range value {
+			if err := e.encode(item); nil != err {
+				return err
+			}
+		}
+		return nil
+	case map[string]interface{}:
+		// keys are sorted so the same value always has the same encoding
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		e.writeHeader(len(value), 0x80, 15, 0, 0xde, 0xdf)
+		for _, key := range keys {
+			if err := e.encode(key); nil != err {
+				return err
+			}
+			if err := e.encode(value[key]); nil != err {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return fmt.Errorf("msgpack: unexpected %T", v)
+}
+
+// writeHeader write length of string, array or map using fix format when it fits,
+// str8 is zero for arrays and maps which have no 8 bit variant
+func (e *Encoder) writeHeader(n int, fix byte, fixMax int, b8, b16, b32 byte) {
+	switch {
+	case n <= fixMax:
+		e.w.WriteByte(fix | byte(n))
+	case b8 != 0 && n <= math.MaxUint8:
+		e.w.WriteByte(b8)
+		e.writeUint(uint64(n), 1)
+	case n <= math.MaxUint16:
+		e.w.WriteByte(b16)
+		e.writeUint(uint64(n), 2)
+	default:
+		e.w.WriteByte(b32)
+		e.writeUint(uint64(n), 4)
+	}
+}
+
+func (e *Encoder) writeInt(i int64) error {
+	switch {
+	case i >= 0 && i <= 127:
+		return e.w.WriteByte(byte(i))
+	case i < 0 && i >= -32:
+		return e.w.WriteByte(byte(i))
+	case i >= 0:
+		e.w.WriteByte(0xcf)
+		return e.writeUint(uint64(i), 8)
+	default:
+		e.w.WriteByte(0xd3)
+		return e.writeUint(uint64(i), 8)
+	}
+}
+
+func (e *Encoder) writeUint(v uint64, size int) error {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], v)
+	_, err := e.w.Write(buf[8-size:])
+	return err
+}
+
+// Decoder read MessagePack values
+type Decoder struct {
+	r     *bufio.Reader
+	depth int
+}
+
+// NewDecoder ...
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReader(r)}
+}
+
+// Decode read one MessagePack value into v
+func (d *Decoder) Decode(v interface{}) error {
+	generic, err := d.decode()
+	if nil != err {
+		return err
+	}
+
+	raw, err := json.Marshal(generic)
+	if nil != err {
+		return err
+	}
+
+	return json.Unmarshal(raw, v)
+}
+
+func (d *Decoder) decode() (interface{}, error) {
+	b, err := d.r.ReadByte()
+	if nil != err {
+		return nil, err
+	}
+
+	switch {
+	case b <= 0x7f:
+		return json.Number(strconv.Itoa(int(b))), nil
+	case b >= 0xe0:
+		return json.Number(strconv.Itoa(int(int8(b)))), nil
+	case b&0xf0 == 0x80:
+		return d.decodeMap(int(b & 0x0f))
+	case b&0xf0 == 0x90:
+		return d.decodeArray(int(b & 0x0f))
+	case b&0xe0 == 0xa0:
+		return d.decodeString(int(b & 0x1f))
+	}
+
+	switch b {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xd9:
+		return d.decodeSized(1, d.decodeString)
+	case 0xc5, 0xda:
+		return d.decodeSized(2, d.decodeString)
+	case 0xc6, 0xdb:
+		return d.decodeSized(4, d.decodeString)
+	case 0xca:
+		u, err := d.readUint(4)
+		return float(float64(math.Float32frombits(uint32(u)))), err
+	case 0xcb:
+		u, err := d.readUint(8)
+		return float(math.Float64frombits(u)), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		u, err := d.readUint(1 << (b - 0xcc))
+		return json.Number(strconv.FormatUint(u, 10)), err
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (b - 0xd0)
+		u, err := d.readUint(size)
+		// sign extend from the encoded size
+		shift := uint(64 - 8*size)
+		return json.Number(strconv.FormatInt(int64(u<<shift)>>shift, 10)), err
+	case 0xdc:
+		return d.decodeSized(2, d.decodeArray)
+	case 0xdd:
+		return d.decodeSized(4, d.decodeArray)
+	case 0xde:
+		return d.decodeSized(2, d.decodeMap)
+	case 0xdf:
+		return d.decodeSized(4, d.decodeMap)
+	}
+
+	return nil, ErrUnsupported
+}
+
+func float(f float64) json.Number {
+	return json.Number(strconv.FormatFloat(f, 'g', -1, 64))
+}
+
+// decodeSized read length of size bytes, checked against MaxLength before it is used
+func (d *Decoder) decodeSized(size int, decode func(n int) (interface{}, error)) (interface{}, error) {
+	n, err := d.readUint(size)
+	if nil != err {
+		return nil, err
+	}
+	if n > MaxLength {
+		return nil, ErrTooLarge
+	}
+	return decode(int(n))
+}
+
+func (d *Decoder) decodeString(n int) (interface{}, error) {
+	var buf bytes.Buffer
+	if _, err := io.CopyN(&buf, d.r, int64(n)); nil != err {
+		if io.EOF == err {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return buf.String(), nil
+}
+
+// nest enter array or map, failing when it is nested deeper than MaxDepth
+func (d *Decoder) nest() error {
+	if d.depth >= MaxDepth {
+		return ErrTooLarge
+	}
+	d.depth++
+	return nil
+}
+
+func prealloc(n int) int {
+	if n > maxPrealloc {
+		return maxPrealloc
+	}
+	return n
+}
+
+func (d *Decoder) decodeArray(n int) (interface{}, error) {
+	if err := d.nest(); nil != err {
+		return nil, err
+	}
+	defer func() { d.depth-- }()
+
+	result := make([]interface{}, 0, prealloc(n))
+	for i := 0; i < n; i++ {
+		item, err := d.decode()
+		if nil != err {
+			return nil, err
+		}
+		result = append(result, item)
+	}
+	return result, nil
+}
+
+func (d *Decoder) decodeMap(n int) (interface{}, error) {
+	if err := d.nest(); nil != err {
+		return nil, err
+	}
+	defer func() { d.depth-- }()
+
+	result := make(map[string]interface{}, prealloc(n))
+	for i := 0; i < n; i++ {
+		key, err := d.decode()
+		if nil != err {
+			return nil, err
+		}
+		value, err := d.decode()
+		if nil != err {
+			return nil, err
+		}
+		result[fmt.Sprint(key)] = value
+	}
+	return result, nil
+}
+
+func (d *Decoder) readUint(size int) (uint64, error) {
+	var buf [8]byte
+	if _, err := io.ReadFull(d.r, buf[8-size:]); nil != err {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(buf[:]), nil
+}
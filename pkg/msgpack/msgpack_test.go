@@ -0,0 +1,149 @@
+package msgpack
+
+import (
+	// internal golang package
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type person struct {
+	Name    string            `json:"name"`
+	Age     int               `json:"age"`
+	Balance int64             `json:"balance"`
+	Score   float64           `json:"score"`
+	Active  bool              `json:"active"`
+	Manager *person           `json:"manager"`
+	Tags    []string          `json:"tags"`
+	Labels  map[string]string `json:"labels"`
+}
+
+func TestRoundTrip(t *testing.T) {
+	tags := make([]string, 20)
+	for i := range tags {
+		tags[i] = strings.Repeat("t", i)
+	}
+
+	tests := []struct {
+		name  string
+		value person
+	}{
+		{"zero", person{}},
+		{"fix formats", person{Name: "ana", Age: 30, Score: 1.5, Active: true, Tags: []string{"a"}, Labels: map[string]string{"k": "v"}}},
+		{"negative numbers", person{Age: -1, Balance: -40000, Score: -0.25}},
+		{"wide numbers", person{Age: 200, Balance: 1 << 40, Score: 1e300}},
+		{"str8", person{Name: strings.Repeat("n", 40)}},
+		{"str16", person{Name: strings.Repeat("n", 300)}},
+		{"array16", person{Tags: tags}},
+		{"nested", person{Name: "ana", Manager: &person{Name: "budi", Tags: []string{}}}},
+		{"unicode", person{Name: "Ñoño 日本"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := Marshal(tt.value)
+			if nil != err {
+				t.Fatal(err)
+			}
+
+			var got person
+			if err = Unmarshal(data, &got); nil != err {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.value) {
+				t.Errorf("got %+v, want %+v", got, tt.value)
+			}
+		})
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  []byte
+	}{
+		{"nil", nil, []byte{0xc0}},
+		{"positive fixint", 5, []byte{0x05}},
+		{"negative fixint", -3, []byte{0xfd}},
+		{"uint64", 200, []byte{0xcf, 0, 0, 0, 0, 0, 0, 0, 0xc8}},
+		{"int64", -300, []byte{0xd3, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xfe, 0xd4}},
+		{"float64", 0.5, []byte{0xcb, 0x3f, 0xe0, 0, 0, 0, 0, 0, 0}},
+		{"fixstr", "ab", []byte{0xa2, 'a', 'b'}},
+		{"fixarray", []bool{true, false}, []byte{0x92, 0xc3, 0xc2}},
+		{"keys sorted", map[string]int{"b": 2, "a": 1}, []byte{0x82, 0xa1, 'a', 0x01, 0xa1, 'b', 0x02}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Marshal(tt.value)
+			if nil != err {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("got % x, want % x", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestUnmarshal formats written by other encoders but not by Encoder
+func TestUnmarshal(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want interface{}
+	}{
+		{"uint8", []byte{0xcc, 0xc8}, float64(200)},
+		{"uint16", []byte{0xcd, 0x01, 0x00}, float64(256)},
+		{"int8", []byte{0xd0, 0x80}, float64(-128)},
+		{"int16", []byte{0xd1, 0xfe, 0xd4}, float64(-300)},
+		{"int32", []byte{0xd2, 0xff, 0xff, 0xff, 0xff}, float64(-1)},
+		{"float32", []byte{0xca, 0x3f, 0xc0, 0x00, 0x00}, float64(1.5)},
+		{"bin8", []byte{0xc4, 0x02, 'h', 'i'}, "hi"},
+		{"map16", []byte{0xde, 0x00, 0x01, 0xa1, 'k', 0xc3}, map[string]interface{}{"k": true}},
+		{"integer keys", []byte{0x81, 0x01, 0xa1, 'v'}, map[string]interface{}{"1": "v"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got interface{}
+			if err := Unmarshal(tt.data, &got); nil != err {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+// nested array of depth arrays around nil
+func nested(depth int) []byte {
+	return append(bytes.Repeat([]byte{0x91}, depth), 0xc0)
+}
+
+func TestDecodeLimits(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"str32 longer than MaxLength", []byte{0xdb, 0x00, 0x10, 0x00, 0x01}, ErrTooLarge},
+		{"bin32 longer than MaxLength", []byte{0xc6, 0xff, 0xff, 0xff, 0xff}, ErrTooLarge},
+		{"array32 longer than MaxLength", []byte{0xdd, 0xff, 0xff, 0xff, 0xff}, ErrTooLarge},
+		{"map32 longer than MaxLength", []byte{0xdf, 0x00, 0x10, 0x00, 0x01}, ErrTooLarge},
+		{"nested deeper than MaxDepth", nested(MaxDepth + 1), ErrTooLarge},
+		{"nested MaxDepth", nested(MaxDepth), nil},
+		{"array16 longer than the input", []byte{0xdc, 0xff, 0xff, 0xc0}, io.EOF},
+		{"str8 longer than the input", []byte{0xd9, 0x05, 'a'}, io.ErrUnexpectedEOF},
+		{"extension", []byte{0xd4, 0x01, 0x00}, ErrUnsupported},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v interface{}
+			if err := Unmarshal(tt.data, &v); err != tt.want {
+				t.Errorf("got error %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package queryable
+
+import (
+	// internal golang package
+	"bytes"
+	"strings"
+)
+
+// Params named params of query, keys are referenced as :key inside conditions.
+// It is an alias so sqlx binds it as a plain map
+type Params = map[string]interface{}
+
+// Builder compose SELECT statement, every condition is combined with AND
+type Builder struct {
+	table   string
+	columns []string
+	where   []string
+	orderBy []string
+	limit   *int
+	params  Params
+}
+
+// Select create builder selecting columns from table
+func Select(table string, columns ...string) *Builder {
+	return &Builder{
+		table:   table,
+		columns: columns,
+		params:  make(Params),
+	}
+}
+
+// Where add condition and its named params, a param with the same name is overwritten
+func (b *Builder) Where(condition string, params Params) *Builder {
+	b.where = append(b.where, condition)
+	for k, v := range params {
+		b.params[k] = v
+	}
+	return b
+}
+
+// OrderBy append order expressions, e.g. "created_date_utc DESC"
+func (b *Builder) OrderBy(orders ...string) *Builder {
+	b.orderBy = append(b.orderBy, orders...)
+	return b
+}
+
+// Limit limit number of rows, bound as :limit
+func (b *Builder) Limit(limit int) *Builder {
+	b.limit = &limit
+	return b
+}
+
+// Build return named query and its params
+func (b *Builder) Build() (string, Params) {
+	var buffer bytes.Buffer
+
+	buffer.WriteString(`SELECT `)
+	buffer.WriteString(strings.Join(b.columns, ", "))
+	b.writeFrom(&buffer)
+
+	if len(b.orderBy) > 0 {
+		buffer.WriteString(` ORDER BY `)
+		buffer.WriteString(strings.Join(b.orderBy, ", "))
+	}
+
+	params := b.copyParams()
+	if nil != b.limit {
+		buffer.WriteString(` LIMIT :limit`)
+		params["limit"] = *b.limit
+	}
+
+	return buffer.String(), params
+}
+
+// Count return named query counting rows matching conditions, order and limit are ignored
+func (b *Builder) Count() (string, Params) {
+	var buffer bytes.Buffer
+
+	buffer.WriteString(`SELECT count(*)`)
+	b.writeFrom(&buffer)
+
+	return buffer.String(), b.copyParams()
+}
+
+func (b *Builder) writeFrom(buffer *bytes.Buffer) {
+	buffer.WriteString(` FROM `)
+	buffer.WriteString(b.table)
+
+	if len(b.where) > 0 {
+		buffer.WriteString(` WHERE `)
+		buffer.WriteString(`(` + strings.Join(b.where, `) AND (`) + `)`)
+	}
+}
+
+func (b *Builder) copyParams() Params {
+	params := make(Params, len(b.params))
+	for k, v := range b.params {
+		params[k] = v
+	}
+	return params
+}
+
+// UpdateBuilder compose UPDATE statement, conditions are combined with AND
+type UpdateBuilder struct {
+	table     string
+	sets      []string
+	where     []string
+	returning []string
+	params    Params
+}
+
+// Update create builder updating rows of table
+func Update(table string) *UpdateBuilder {
+	return &UpdateBuilder{
+		table:  table,
+		params: make(Params),
+	}
+}
+
+// Set assign value to column, bound as :set_column so it never clash with conditions
+func (b *UpdateBuilder) Set(column string, value interface{}) *UpdateBuilder {
+	name := "set_" + column
+	b.sets = append(b.sets, column+` = :`+name)
+	b.params[name] = value
+	return b
+}
+
+// SetExpr assign raw sql expression to column, e.g. CURRENT_TIMESTAMP
+func (b *UpdateBuilder) SetExpr(column string, expr string) *UpdateBuilder {
+	b.sets = append(b.sets, column+` = `+expr)
+	return b
+}
+
+// Where add condition and its named params, a param with the same name is overwritten
+func (b *UpdateBuilder) Where(condition string, params Params) *UpdateBuilder {
+	b.where = append(b.where, condition)
+	for k, v := range params {
+		b.params[k] = v
+	}
+	return b
+}
+
+// Returning return columns of updated rows
+func (b *UpdateBuilder) Returning(columns ...string) *UpdateBuilder {
+	b.returning = append(b.returning, columns...)
+	return b
+}
+
+// Build return named query and its params
+func (b *UpdateBuilder) Build() (string, Params) {
+	var buffer bytes.Buffer
+
+	buffer.WriteString(`UPDATE `)
+	buffer.WriteString(b.table)
+	buffer.WriteString(` SET `)
+	buffer.WriteString(strings.Join(b.sets, ", "))
+
+	if len(b.where) > 0 {
+		buffer.WriteString(` WHERE `)
+		buffer.WriteString(`(` + strings.Join(b.where, `) AND (`) + `)`)
+	}
+
+	if len(b.returning) > 0 {
+		buffer.WriteString(` RETURNING `)
+		buffer.WriteString(strings.Join(b.returning, ", "))
+	}
+
+	params := make(Params, len(b.params))
+	for k, v := range b.params {
+		params[k] = v
+	}
+
+	return buffer.String(), params
+}
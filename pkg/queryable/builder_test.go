@@ -0,0 +1,154 @@
+package queryable
+
+import (
+	// internal golang package
+	"reflect"
+	"testing"
+
+	// thirdparty package
+	"github.com/jmoiron/sqlx"
+)
+
+func TestBuilder(t *testing.T) {
+	tests := []struct {
+		name   string
+		build  func() *Builder
+		query  string
+		count  string
+		params Params
+	}{
+		{
+			name:   "no condition",
+			build:  func() *Builder { return Select(`phone_book`, `id`, `fullname`) },
+			query:  `SELECT id, fullname FROM phone_book`,
+			count:  `SELECT count(*) FROM phone_book`,
+			params: Params{},
+		},
+		{
+			name: "combined filters, order and limit",
+			build: func() *Builder {
+				return Select(`phone_book`, `id`).
+					Where(`deleted_date_utc IS NULL`, nil).
+					Where(`fullname ILIKE :fullname`, Params{"fullname": "%ann%"}).
+					Where(`address ILIKE :address OR fullname = :fullname`, Params{"address": "%street%"}).
+					OrderBy(`created_date_utc`, `id`).
+					Limit(21)
+			},
+			query: `SELECT id FROM phone_book WHERE (deleted_date_utc IS NULL) AND (fullname ILIKE :fullname) AND ` +
+				`(address ILIKE :address OR fullname = :fullname) ORDER BY created_date_utc, id LIMIT :limit`,
+			count: `SELECT count(*) FROM phone_book WHERE (deleted_date_utc IS NULL) AND (fullname ILIKE :fullname) AND ` +
+				`(address ILIKE :address OR fullname = :fullname)`,
+			params: Params{"fullname": "%ann%", "address": "%street%", "limit": 21},
+		},
+		{
+			name: "param with the same name is overwritten",
+			build: func() *Builder {
+				return Select(`phone_book`, `id`).
+					Where(`created_date_utc > :after`, Params{"after": 1}).
+					Where(`created_date_utc < :after`, Params{"after": 2})
+			},
+			query:  `SELECT id FROM phone_book WHERE (created_date_utc > :after) AND (created_date_utc < :after)`,
+			count:  `SELECT count(*) FROM phone_book WHERE (created_date_utc > :after) AND (created_date_utc < :after)`,
+			params: Params{"after": 2},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			query, params := test.build().Build()
+			if query != test.query {
+				t.Errorf("got query\n%s\nwant\n%s", query, test.query)
+			}
+			if !reflect.DeepEqual(params, test.params) {
+				t.Errorf("got params %v, want %v", params, test.params)
+			}
+			if _, _, err := sqlx.Named(query, params); nil != err {
+				t.Errorf("query does not bind: %v", err)
+			}
+
+			count, countParams := test.build().Count()
+			if count != test.count {
+				t.Errorf("got count\n%s\nwant\n%s", count, test.count)
+			}
+			if _, _, err := sqlx.Named(count, countParams); nil != err {
+				t.Errorf("count does not bind: %v", err)
+			}
+		})
+	}
+}
+
+func TestBuilderParamsAreCopied(t *testing.T) {
+	b := Select(`phone_book`, `id`).Where(`id = :id`, Params{"id": 1}).Limit(10)
+
+	_, params := b.Build()
+	params["id"] = 2
+
+	_, again := b.Build()
+	if again["id"] != 1 {
+		t.Errorf("got id %v after changing built params, want 1", again["id"])
+	}
+	if _, ok := b.params["limit"]; ok {
+		t.Error("limit leaked into params of builder, Count would bind it")
+	}
+}
+
+func TestUpdateBuilder(t *testing.T) {
+	tests := []struct {
+		name   string
+		build  func() *UpdateBuilder
+		query  string
+		params Params
+	}{
+		{
+			name: "values are prefixed so they never clash with conditions",
+			build: func() *UpdateBuilder {
+				return Update(`phone_book`).
+					Set(`fullname`, "Ann").
+					Set(`version`, 7).
+					SetExpr(`updated_date_utc`, `CURRENT_TIMESTAMP`).
+					Where(`id = :id`, Params{"id": "00000000-0000-0000-0000-000000000001"}).
+					Where(`version = :version`, Params{"version": 6}).
+					Returning(`id`, `version`)
+			},
+			query: `UPDATE phone_book SET fullname = :set_fullname, version = :set_version, updated_date_utc = CURRENT_TIMESTAMP ` +
+				`WHERE (id = :id) AND (version = :version) RETURNING id, version`,
+			params: Params{
+				"set_fullname": "Ann",
+				"set_version":  7,
+				"id":           "00000000-0000-0000-0000-000000000001",
+				"version":      6,
+			},
+		},
+		{
+			name: "expression only, no condition",
+			build: func() *UpdateBuilder {
+				return Update(`export_job`).SetExpr(`version`, `version + 1`)
+			},
+			query:  `UPDATE export_job SET version = version + 1`,
+			params: Params{},
+		},
+		{
+			name: "nil value is bound",
+			build: func() *UpdateBuilder {
+				return Update(`phone_book`).Set(`phone_number_e164`, nil).Where(`id = :id`, Params{"id": 1})
+			},
+			query:  `UPDATE phone_book SET phone_number_e164 = :set_phone_number_e164 WHERE (id = :id)`,
+			params: Params{"set_phone_number_e164": nil, "id": 1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			query, params := test.build().Build()
+			if query != test.query {
+				t.Errorf("got query\n%s\nwant\n%s", query, test.query)
+			}
+			if !reflect.DeepEqual(params, test.params) {
+				t.Errorf("got params %v, want %v", params, test.params)
+			}
+			if _, _, err := sqlx.Named(query, params); nil != err {
+				t.Errorf("query does not bind: %v", err)
+			}
+		})
+	}
+}
@@ -4,10 +4,17 @@ import (
 	// internal golang package
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strconv"
+	"time"
+
+	// internal package
+	"phonebook/pkg/tenant"
 
 	// thirdparty package
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 // Q ...
@@ -40,6 +47,115 @@ type Queryable struct {
 	q  Q
 	db *sqlx.DB
 	tx *sqlx.Tx
+	// depth count savepoints opened inside tx, scope is the tenant scope set on tx
+	depth int
+	scope scope
+}
+
+// Q return the transaction or database wrapped by queryable
+func (q Queryable) Q() Q {
+	return q.q
+}
+
+// Queryable run statements on what it wraps, so code holding it does not need to know
+// whether it is inside a transaction
+var _ Q = Queryable{}
+
+func (q Queryable) BindNamed(query string, arg interface{}) (string, []interface{}, error) {
+	return q.q.BindNamed(query, arg)
+}
+
+func (q Queryable) DriverName() string {
+	return q.q.DriverName()
+}
+
+func (q Queryable) Get(dest interface{}, query string, args ...interface{}) error {
+	return q.q.Get(dest, query, args...)
+}
+
+func (q Queryable) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return q.q.GetContext(ctx, dest, query, args...)
+}
+
+func (q Queryable) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return q.q.Exec(query, args...)
+}
+
+func (q Queryable) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return q.q.ExecContext(ctx, query, args...)
+}
+
+func (q Queryable) MustExec(query string, args ...interface{}) sql.Result {
+	return q.q.MustExec(query, args...)
+}
+
+func (q Queryable) MustExecContext(ctx context.Context, query string, args ...interface{}) sql.Result {
+	return q.q.MustExecContext(ctx, query, args...)
+}
+
+func (q Queryable) NamedExec(query string, arg interface{}) (sql.Result, error) {
+	return q.q.NamedExec(query, arg)
+}
+
+func (q Queryable) NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error) {
+	return q.q.NamedExecContext(ctx, query, arg)
+}
+
+func (q Queryable) NamedQuery(query string, arg interface{}) (*sqlx.Rows, error) {
+	return q.q.NamedQuery(query, arg)
+}
+
+// NamedQueryContext sqlx.Tx has no NamedQueryContext, the query is bound then run with QueryxContext
+func (q Queryable) NamedQueryContext(ctx context.Context, query string, arg interface{}) (*sqlx.Rows, error) {
+	query, args, err := q.q.BindNamed(query, arg)
+	if nil != err {
+		return nil, err
+	}
+	return q.q.QueryxContext(ctx, query, args...)
+}
+
+func (q Queryable) PrepareNamed(query string) (*sqlx.NamedStmt, error) {
+	return q.q.PrepareNamed(query)
+}
+
+func (q Queryable) PrepareNamedContext(ctx context.Context, query string) (*sqlx.NamedStmt, error) {
+	return q.q.PrepareNamedContext(ctx, query)
+}
+
+func (q Queryable) Preparex(query string) (*sqlx.Stmt, error) {
+	return q.q.Preparex(query)
+}
+
+func (q Queryable) PreparexContext(ctx context.Context, query string) (*sqlx.Stmt, error) {
+	return q.q.PreparexContext(ctx, query)
+}
+
+func (q Queryable) QueryRowx(query string, args ...interface{}) *sqlx.Row {
+	return q.q.QueryRowx(query, args...)
+}
+
+func (q Queryable) QueryRowxContext(ctx context.Context, query string, args ...interface{}) *sqlx.Row {
+	return q.q.QueryRowxContext(ctx, query, args...)
+}
+
+func (q Queryable) Queryx(query string, args ...interface{}) (*sqlx.Rows, error) {
+	return q.q.Queryx(query, args...)
+}
+
+func (q Queryable) QueryxContext(ctx context.Context, query string, args ...interface{}) (*sqlx.Rows, error) {
+	return q.q.QueryxContext(ctx, query, args...)
+}
+
+func (q Queryable) Rebind(query string) string {
+	return q.q.Rebind(query)
+}
+
+func (q Queryable) Select(dest interface{}, query string, args ...interface{}) error {
+	return q.q.Select(dest, query, args...)
+}
+
+func (q Queryable) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return q.q.SelectContext(ctx, dest, query, args...)
 }
 
 type key int
@@ -75,13 +191,110 @@ func NewQueryable(db interface{}) Queryable {
 	}
 }
 
-func RunInTransaction(ctx context.Context, db *sqlx.DB, fn func(ctx context.Context) error) error {
-	tx, err := db.Beginx()
+const (
+	// serializationFailure SQLSTATE of a transaction that must be retried
+	serializationFailure = "40001"
+	maxBackoff           = time.Second
+
+	// DefaultRetries and DefaultBackoff retry policy of request handlers whose work is
+	// only done in the database
+	DefaultRetries = 3
+	DefaultBackoff = 20 * time.Millisecond
+)
+
+// TxOption configure transaction started by RunInTransaction
+type TxOption func(*txConfig)
+
+type txConfig struct {
+	options sql.TxOptions
+	retries int
+	backoff time.Duration
+}
+
+// Isolation run transaction at level instead of the database default
+func Isolation(level sql.IsolationLevel) TxOption {
+	return func(c *txConfig) {
+		c.options.Isolation = level
+	}
+}
+
+// ReadOnly run transaction in read only mode
+func ReadOnly() TxOption {
+	return func(c *txConfig) {
+		c.options.ReadOnly = true
+	}
+}
+
+// Retry run transaction again up to retries times when it fails with a serialization
+// failure, waiting backoff doubled after each attempt up to a second. fn run once per
+// attempt, so only opt in when everything it does is undone by the rollback
+func Retry(retries int, backoff time.Duration) TxOption {
+	return func(c *txConfig) {
+		c.retries = retries
+		c.backoff = backoff
+	}
+}
+
+// RunInTransaction run fn with a queryable of a transaction in its context, committed when fn
+// succeed and rolled back otherwise. When ctx already carry a transaction fn run inside a
+// savepoint of it instead, and opts only apply to the outermost transaction. fn run once
+// unless Retry is given
+func RunInTransaction(ctx context.Context, db *sqlx.DB, fn func(ctx context.Context) error, opts ...TxOption) error {
+	if outer, ok := QueryableFromContext(ctx); ok && nil != outer.tx {
+		return runInSavepoint(ctx, outer, fn)
+	}
+
+	cfg := txConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	return retry(ctx, cfg, func() error {
+		return runInTransaction(ctx, db, &cfg.options, fn)
+	})
+}
+
+// wait is replaced by tests to observe backoff without sleeping
+var wait = time.After
+
+// retry run attempt until it does not fail with a serialization failure or cfg.retries
+// attempts were made after the first one
+func retry(ctx context.Context, cfg txConfig, attempt func() error) error {
+	backoff := cfg.backoff
+	for n := 0; ; n++ {
+		err := attempt()
+		if n >= cfg.retries || !isSerializationFailure(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-wait(backoff):
+		}
+
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+func runInTransaction(ctx context.Context, db *sqlx.DB, options *sql.TxOptions, fn func(ctx context.Context) error) error {
+	tx, err := db.BeginTxx(ctx, options)
 	if nil != err {
 		return err
 	}
 
-	ctx = NewQueryableContext(ctx, NewQueryable(tx))
+	current := scopeOf(ctx)
+	if current != (scope{}) {
+		err = setScope(ctx, tx, current)
+		if nil != err {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	ctx = NewQueryableContext(ctx, Queryable{q: tx, tx: tx, scope: current})
 	err = fn(ctx)
 
 	if nil != err {
@@ -90,9 +303,86 @@ func RunInTransaction(ctx context.Context, db *sqlx.DB, fn func(ctx context.Cont
 	}
 
 	err = tx.Commit()
-	if err != nil {
-		return fmt.Errorf("error when committing transaction: %v", err)
+	if nil != err {
+		return fmt.Errorf("error when committing transaction: %w", err)
 	}
 
 	return nil
 }
+
+// runInSavepoint run fn inside a savepoint of the transaction of outer, only the work of fn
+// is undone when it fails. A different tenant scope is set for fn and restored afterward
+func runInSavepoint(ctx context.Context, outer Queryable, fn func(ctx context.Context) error) error {
+	inner := outer
+	inner.depth++
+	inner.scope = scopeOf(ctx)
+	name := "sp_" + strconv.Itoa(inner.depth)
+
+	_, err := outer.tx.ExecContext(ctx, `SAVEPOINT `+name)
+	if nil != err {
+		return err
+	}
+
+	if inner.scope != outer.scope {
+		err = setScope(ctx, outer.tx, inner.scope)
+	}
+
+	if nil == err {
+		err = fn(NewQueryableContext(ctx, inner))
+	}
+
+	if nil != err {
+		// rolling back to the savepoint also undo the scope set for fn, it stays open until
+		// released. When either fails the outer transaction can not go on and must roll back
+		_, rollbackErr := outer.tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT `+name)
+		if nil == rollbackErr {
+			_, rollbackErr = outer.tx.ExecContext(ctx, `RELEASE SAVEPOINT `+name)
+		}
+		if nil != rollbackErr {
+			return fmt.Errorf("error when rolling back savepoint %s: %v, after: %w", name, rollbackErr, err)
+		}
+		return err
+	}
+
+	_, err = outer.tx.ExecContext(ctx, `RELEASE SAVEPOINT `+name)
+	if nil != err {
+		return err
+	}
+
+	if inner.scope != outer.scope {
+		return setScope(ctx, outer.tx, outer.scope)
+	}
+	return nil
+}
+
+func isSerializationFailure(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == serializationFailure
+}
+
+// scope tenant visibility of a transaction, rows of one tenant, of every tenant in
+// system scope or of none
+type scope struct {
+	tenant string
+	system bool
+}
+
+func scopeOf(ctx context.Context) scope {
+	if tenant.IsSystem(ctx) {
+		return scope{system: true}
+	}
+
+	id, _ := tenant.FromContext(ctx)
+	return scope{tenant: id}
+}
+
+// setScope expose scope to row level security policies for the lifetime of tx
+func setScope(ctx context.Context, tx *sqlx.Tx, s scope) error {
+	bypass := "off"
+	if s.system {
+		bypass = "on"
+	}
+
+	_, err := tx.ExecContext(ctx, `SELECT set_config('app.tenant_id', $1, true), set_config('app.bypass_tenant', $2, true)`, s.tenant, bypass)
+	return err
+}
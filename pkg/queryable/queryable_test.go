@@ -0,0 +1,130 @@
+package queryable
+
+import (
+	// internal golang package
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	// thirdparty package
+	"github.com/lib/pq"
+)
+
+// recordWaits replace wait with a function recording backoff and returning at once,
+// until restore is called
+func recordWaits() (*[]time.Duration, func()) {
+	waits := make([]time.Duration, 0)
+	wait = func(d time.Duration) <-chan time.Time {
+		waits = append(waits, d)
+		ch := make(chan time.Time, 1)
+		ch <- time.Now()
+		return ch
+	}
+	return &waits, func() { wait = time.After }
+}
+
+func TestRetry(t *testing.T) {
+	serialization := &pq.Error{Code: serializationFailure}
+	other := errors.New("connection refused")
+
+	tests := []struct {
+		name     string
+		cfg      txConfig
+		failures []error
+		err      error
+		attempts int
+		waits    []time.Duration
+	}{
+		{
+			name:     "not retried by default",
+			failures: []error{serialization},
+			err:      serialization,
+			attempts: 1,
+			waits:    []time.Duration{},
+		},
+		{
+			name:     "retried until it succeed",
+			cfg:      txConfig{retries: 3, backoff: 20 * time.Millisecond},
+			failures: []error{serialization, serialization},
+			attempts: 3,
+			waits:    []time.Duration{20 * time.Millisecond, 40 * time.Millisecond},
+		},
+		{
+			name:     "wrapped failure is retried",
+			cfg:      txConfig{retries: 1, backoff: time.Millisecond},
+			failures: []error{fmt.Errorf("error when committing transaction: %w", serialization)},
+			attempts: 2,
+			waits:    []time.Duration{time.Millisecond},
+		},
+		{
+			name:     "gives up after retries",
+			cfg:      txConfig{retries: 2, backoff: time.Millisecond},
+			failures: []error{serialization, serialization, serialization, serialization},
+			err:      serialization,
+			attempts: 3,
+			waits:    []time.Duration{time.Millisecond, 2 * time.Millisecond},
+		},
+		{
+			name:     "backoff is capped",
+			cfg:      txConfig{retries: 4, backoff: 400 * time.Millisecond},
+			failures: []error{serialization, serialization, serialization, serialization, serialization},
+			err:      serialization,
+			attempts: 5,
+			waits:    []time.Duration{400 * time.Millisecond, 800 * time.Millisecond, maxBackoff, maxBackoff},
+		},
+		{
+			name:     "other errors are not retried",
+			cfg:      txConfig{retries: 3, backoff: time.Millisecond},
+			failures: []error{other},
+			err:      other,
+			attempts: 1,
+			waits:    []time.Duration{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			waits, restore := recordWaits()
+			defer restore()
+
+			attempts := 0
+			err := retry(context.Background(), test.cfg, func() error {
+				attempts++
+				if attempts <= len(test.failures) {
+					return test.failures[attempts-1]
+				}
+				return nil
+			})
+
+			if !errors.Is(err, test.err) {
+				t.Errorf("got error %v, want %v", err, test.err)
+			}
+			if attempts != test.attempts {
+				t.Errorf("got %d attempts, want %d", attempts, test.attempts)
+			}
+			if fmt.Sprint(*waits) != fmt.Sprint(test.waits) {
+				t.Errorf("got waits %v, want %v", *waits, test.waits)
+			}
+		})
+	}
+}
+
+func TestRetryStopWhenContextIsDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	wait = func(d time.Duration) <-chan time.Time { return make(chan time.Time) }
+	defer func() { wait = time.After }()
+
+	attempts := 0
+	serialization := &pq.Error{Code: serializationFailure}
+	err := retry(ctx, txConfig{retries: 3, backoff: time.Millisecond}, func() error {
+		attempts++
+		return serialization
+	})
+
+	if err != serialization || attempts != 1 {
+		t.Errorf("got (%v, %d attempts), want (%v, 1 attempt)", err, attempts, serialization)
+	}
+}
@@ -25,6 +25,8 @@ type Logger struct {
 type HTTPOption struct {
 	DecodeModel interface{}
 	Logger      *Logger
+	//Middlewares run before validation, e.g. authorization of the route
+	Middlewares []endpoint.Middleware
 }
 
 //NewHTTPServer create go kit HTTP server
@@ -36,6 +38,10 @@ func NewHTTPServer(e endpoint.Endpoint, httpOpt HTTPOption, options ...http.Serv
 		middlewares = endpoint.Chain(mval)
 	}
 
+	for i := len(httpOpt.Middlewares) - 1; i >= 0; i-- {
+		middlewares = endpoint.Chain(httpOpt.Middlewares[i], middlewares)
+	}
+
 	if httpOpt.Logger != nil {
 		mlog := middleware.LogAndInstrumentation(
 			httpOpt.Logger.Logger,
@@ -49,6 +55,11 @@ func NewHTTPServer(e endpoint.Endpoint, httpOpt HTTPOption, options ...http.Serv
 
 	e = middlewares(e)
 
+	// Accept of request is needed for content negotiation, it is resolved after options
+	// that may replace it and before the request is decoded
+	options = append([]http.ServerOption{http.ServerBefore(http.PopulateRequestContext)}, options...)
+	options = append(options, http.ServerBefore(httpserver.Negotiate))
+
 	return http.NewServer(e, httpserver.Decode(httpOpt.DecodeModel), httpserver.Encode(), options...)
 }
 
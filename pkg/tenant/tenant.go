@@ -0,0 +1,103 @@
+package tenant
+
+import (
+	// internal golang package
+	"context"
+	"net/http"
+	"strings"
+
+	// internal package
+	"phonebook/pkg/auth"
+	"phonebook/pkg/httperror"
+)
+
+// Header carry tenant of caller whose credentials are not bound to a tenant and
+// allow to act across tenants
+const Header = "X-Tenant-ID"
+
+// maxLength length of tenant_id columns
+const maxLength = 64
+
+var (
+	ErrRequired = httperror.New(httperror.Forbidden, "tenant_required", "tenant is required")
+	ErrMismatch = httperror.New(httperror.Forbidden, "tenant_mismatch", "tenant does not match credentials")
+	ErrInvalid  = httperror.New(httperror.Invalid, "tenant_invalid", "tenant is not valid")
+)
+
+// scope tenant of work, system scope is not bound to any tenant
+type scope struct {
+	id     string
+	system bool
+}
+
+type key int
+
+const scopeKey key = 0
+
+// NewContext bind work in ctx to tenant id
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, scopeKey, scope{id: id})
+}
+
+// System mark work in ctx as spanning every tenant, used by background jobs
+// and lookups needed before the tenant is known
+func System(ctx context.Context) context.Context {
+	return context.WithValue(ctx, scopeKey, scope{system: true})
+}
+
+// FromContext tenant id bound to ctx
+func FromContext(ctx context.Context) (string, bool) {
+	s, ok := ctx.Value(scopeKey).(scope)
+	if !ok || s.system {
+		return "", false
+	}
+	return s.id, true
+}
+
+// IsSystem report whether ctx is marked by System
+func IsSystem(ctx context.Context) bool {
+	s, ok := ctx.Value(scopeKey).(scope)
+	return ok && s.system
+}
+
+// Resolve tenant of principal, the header is only used when the credentials are
+// not bound to a tenant and allow to act across tenants, and must match them otherwise
+func Resolve(principal *auth.Principal, header string) (string, error) {
+	header = strings.TrimSpace(header)
+
+	var id string
+	switch {
+	case nil == principal:
+	case principal.Tenant != "":
+		if header != "" && header != principal.Tenant {
+			return "", ErrMismatch
+		}
+		id = principal.Tenant
+	case principal.CrossTenant:
+		id = header
+	}
+
+	if id == "" {
+		return "", ErrRequired
+	}
+
+	if len(id) > maxLength {
+		return "", ErrInvalid
+	}
+
+	return id, nil
+}
+
+// Middleware bind request context to tenant of authenticated principal, it must run after auth.Middleware
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, _ := auth.FromContext(r.Context())
+		id, err := Resolve(principal, r.Header.Get(Header))
+		if nil != err {
+			httperror.EncodeError(r.Context(), err, w)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), id)))
+	})
+}
@@ -0,0 +1,43 @@
+package tenant
+
+import (
+	// internal golang package
+	"strings"
+	"testing"
+
+	// internal package
+	"phonebook/pkg/auth"
+)
+
+func TestResolve(t *testing.T) {
+	bound := &auth.Principal{ID: "key", Tenant: "acme"}
+	unbound := &auth.Principal{ID: "user"}
+	crossTenant := &auth.Principal{ID: "operator", CrossTenant: true}
+
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		header    string
+		id        string
+		err       error
+	}{
+		{name: "bound without header", principal: bound, id: "acme"},
+		{name: "bound with its own tenant", principal: bound, header: "acme", id: "acme"},
+		{name: "bound with another tenant", principal: bound, header: "other", err: ErrMismatch},
+		{name: "unbound without header", principal: unbound, err: ErrRequired},
+		{name: "unbound can not pick a tenant", principal: unbound, header: "acme", err: ErrRequired},
+		{name: "cross tenant pick a tenant", principal: crossTenant, header: " acme ", id: "acme"},
+		{name: "cross tenant without header", principal: crossTenant, err: ErrRequired},
+		{name: "cross tenant with a long tenant", principal: crossTenant, header: strings.Repeat("a", maxLength+1), err: ErrInvalid},
+		{name: "anonymous with header", header: "acme", err: ErrRequired},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			id, err := Resolve(test.principal, test.header)
+			if id != test.id || err != test.err {
+				t.Errorf("got (%q, %v), want (%q, %v)", id, err, test.id, test.err)
+			}
+		})
+	}
+}
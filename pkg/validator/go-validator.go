@@ -31,10 +31,15 @@ func New() *Validator {
 		Trans:    trans,
 	}
 
+	validate.RegisterValidation("phone", isPhone)
+	validate.RegisterValidation("e164", isE164)
+
 	v.RegisterMessages(map[string]string{
 		"required": "{field} is required",
 		"min":      "{field} min {param}",
 		"max":      "{field} max {param}",
+		"phone":    "{field} is not a valid phone number",
+		"e164":     "{field} must be in E.164 format",
 	})
 
 	return v
@@ -0,0 +1,143 @@
+package validator
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"sync"
+
+	validator "gopkg.in/go-playground/validator.v9"
+)
+
+// ErrInvalidPhone phone number can not be converted into E.164
+var ErrInvalidPhone = errors.New("invalid_phone_number")
+
+var e164Regex = regexp.MustCompile(`^\+[1-9][0-9]{7,14}$`)
+
+// region dialing rule of a country
+type region struct {
+	callingCode string
+	trunkPrefix string
+}
+
+// regions supported as default region of national numbers, keyed by ISO 3166-1 alpha-2
+var regions = map[string]region{
+	"AU": {callingCode: "61", trunkPrefix: "0"},
+	"CN": {callingCode: "86", trunkPrefix: "0"},
+	"DE": {callingCode: "49", trunkPrefix: "0"},
+	"GB": {callingCode: "44", trunkPrefix: "0"},
+	"ID": {callingCode: "62", trunkPrefix: "0"},
+	"IN": {callingCode: "91", trunkPrefix: "0"},
+	"JP": {callingCode: "81", trunkPrefix: "0"},
+	"MY": {callingCode: "60", trunkPrefix: "0"},
+	"NL": {callingCode: "31", trunkPrefix: "0"},
+	"PH": {callingCode: "63", trunkPrefix: "0"},
+	"SG": {callingCode: "65"},
+	"TH": {callingCode: "66", trunkPrefix: "0"},
+	"US": {callingCode: "1", trunkPrefix: "1"},
+	"VN": {callingCode: "84", trunkPrefix: "0"},
+}
+
+var phoneRegion = "ID"
+var phoneRegionLock sync.RWMutex
+
+// IsPhoneRegion report whether region code is supported as default region
+func IsPhoneRegion(code string) bool {
+	_, ok := regions[strings.ToUpper(code)]
+	return ok
+}
+
+// SetPhoneRegion set default region used by the phone tag, it returns false when region is not supported
+func SetPhoneRegion(code string) bool {
+	if !IsPhoneRegion(code) {
+		return false
+	}
+	code = strings.ToUpper(code)
+
+	phoneRegionLock.Lock()
+	phoneRegion = code
+	phoneRegionLock.Unlock()
+	return true
+}
+
+// PhoneRegion default region used by the phone tag
+func PhoneRegion() string {
+	phoneRegionLock.RLock()
+	defer phoneRegionLock.RUnlock()
+	return phoneRegion
+}
+
+// IsE164 report whether number is already formatted as E.164
+func IsE164(number string) bool {
+	return e164Regex.MatchString(number)
+}
+
+// NormalizePhone convert number into E.164, national numbers are read using the
+// dialing rule of regionCode and a leading 00 is read as international prefix.
+// Spaces, dashes, dots and parentheses are ignored
+func NormalizePhone(number string, regionCode string) (string, error) {
+	result, err := international(number, regionCode)
+	if nil != err {
+		return "", err
+	}
+
+	if !IsE164(result) {
+		return "", ErrInvalidPhone
+	}
+
+	return result, nil
+}
+
+// PhonePrefix convert partial number into the international prefix it starts with,
+// it follows NormalizePhone without checking the length of the number
+func PhonePrefix(number string, regionCode string) (string, error) {
+	return international(number, regionCode)
+}
+
+func international(number string, regionCode string) (string, error) {
+	rule, ok := regions[strings.ToUpper(regionCode)]
+	if !ok {
+		return "", ErrInvalidPhone
+	}
+
+	number = strings.TrimSpace(number)
+	isInternational := strings.HasPrefix(number, "+")
+
+	var digits strings.Builder
+	for _, r := range number {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == ' ' || r == '-' || r == '.' || r == '(' || r == ')' || r == '/':
+		case r == '+' && digits.Len() == 0:
+		default:
+			return "", ErrInvalidPhone
+		}
+	}
+
+	national := digits.String()
+	if national == "" {
+		return "", ErrInvalidPhone
+	}
+
+	switch {
+	case isInternational:
+	case strings.HasPrefix(national, "00"):
+		national = national[2:]
+	case rule.trunkPrefix != "" && strings.HasPrefix(national, rule.trunkPrefix):
+		national = rule.callingCode + strings.TrimPrefix(national, rule.trunkPrefix)
+	default:
+		national = rule.callingCode + national
+	}
+
+	return "+" + national, nil
+}
+
+func isPhone(fl validator.FieldLevel) bool {
+	_, err := NormalizePhone(fl.Field().String(), PhoneRegion())
+	return nil == err
+}
+
+func isE164(fl validator.FieldLevel) bool {
+	return IsE164(fl.Field().String())
+}
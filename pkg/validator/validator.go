@@ -2,6 +2,8 @@ package validator
 
 import (
 	"sync"
+
+	validator "gopkg.in/go-playground/validator.v9"
 )
 
 //ValidationFunc function used in server validator
@@ -12,11 +14,32 @@ var val *Validator
 
 //DefaultValidator execute ValidateStruct
 func DefaultValidator() ValidationFunc {
-	onceVal.Do(func() {
-		val = New()
-	})
+	defaultValidator()
 
 	return func(req interface{}) error {
 		return val.Validate.Struct(req)
 	}
 }
+
+//Translate translate validation errors into pairs of field and message,
+//ok is false when err is not produced by validator
+func Translate(err error) (messages map[string]string, ok bool) {
+	verrs, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return nil, false
+	}
+
+	defaultValidator()
+
+	messages = make(map[string]string)
+	for _, verr := range verrs {
+		messages[verr.Field()] = verr.Translate(val.Trans)
+	}
+	return messages, true
+}
+
+func defaultValidator() {
+	onceVal.Do(func() {
+		val = New()
+	})
+}
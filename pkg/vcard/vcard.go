@@ -0,0 +1,338 @@
+package vcard
+
+import (
+	// internal golang package
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+)
+
+// supported versions
+const (
+	Version3 = "3.0"
+	Version4 = "4.0"
+)
+
+// MediaType of vCard as registered by RFC 6350
+const MediaType = "text/vcard"
+
+// ErrMalformed card is not enclosed by BEGIN:VCARD and END:VCARD
+var ErrMalformed = errors.New("malformed_vcard")
+
+// Value typed value of a multi valued property such as TEL, EMAIL or ADR
+type Value struct {
+	Types     []string
+	Preferred bool
+	Value     string
+}
+
+// Card subset of RFC 6350 vCard used by the phonebook
+type Card struct {
+	UID           string
+	FormattedName string
+	Tels          []Value
+	Emails        []Value
+	Addresses     []Value
+	Revision      string
+}
+
+// Encoder write cards in one version
+type Encoder struct {
+	w       io.Writer
+	version string
+}
+
+// NewEncoder create encoder writing version 4.0 unless version is 3.0
+func NewEncoder(w io.Writer, version string) *Encoder {
+	if version != Version3 {
+		version = Version4
+	}
+	return &Encoder{w: w, version: version}
+}
+
+// Encode write one card
+func (e *Encoder) Encode(card *Card) error {
+	var buffer bytes.Buffer
+
+	writeLine(&buffer, "BEGIN:VCARD")
+	writeLine(&buffer, "VERSION:"+e.version)
+	if card.UID != "" {
+		if e.version == Version4 {
+			writeLine(&buffer, "UID:urn:uuid:"+card.UID)
+		} else {
+			writeLine(&buffer, "UID:"+card.UID)
+		}
+	}
+	writeLine(&buffer, "FN:"+escape(card.FormattedName))
+	// N is required by 3.0, the formatted name is kept as family name
+	writeLine(&buffer, "N:"+escape(card.FormattedName)+";;;;")
+
+	for _, tel := range card.Tels {
+		if e.version == Version4 {
+			writeLine(&buffer, "TEL;VALUE=uri"+e.params(tel)+":tel:"+tel.Value)
+		} else {
+			writeLine(&buffer, "TEL"+e.params(tel)+":"+tel.Value)
+		}
+	}
+	for _, email := range card.Emails {
+		writeLine(&buffer, "EMAIL"+e.params(email)+":"+escape(email.Value))
+	}
+	for _, address := range card.Addresses {
+		// free form address is kept in the street component
+		writeLine(&buffer, "ADR"+e.params(address)+":;;"+escape(address.Value)+";;;;")
+	}
+
+	if card.Revision != "" {
+		writeLine(&buffer, "REV:"+card.Revision)
+	}
+	writeLine(&buffer, "END:VCARD")
+
+	_, err := e.w.Write(buffer.Bytes())
+	return err
+}
+
+// params TYPE and PREF parameters in the syntax of encoder version
+func (e *Encoder) params(v Value) string {
+	types := make([]string, 0, len(v.Types)+1)
+	for _, t := range v.Types {
+		if e.version == Version3 {
+			t = strings.ToUpper(t)
+		}
+		types = append(types, t)
+	}
+
+	if v.Preferred && e.version == Version3 {
+		types = append(types, "PREF")
+	}
+
+	result := ""
+	if len(types) > 0 {
+		result += ";TYPE=" + strings.Join(types, ",")
+	}
+	if v.Preferred && e.version == Version4 {
+		result += ";PREF=1"
+	}
+	return result
+}
+
+// writeLine write content line folded at 75 octets and terminated by CRLF
+func writeLine(buffer *bytes.Buffer, line string) {
+	const limit = 75
+
+	for len(line) > limit {
+		cut := limit
+		// never split a multi byte rune
+		for cut > 0 && !isRuneStart(line[cut]) {
+			cut--
+		}
+		buffer.WriteString(line[:cut])
+		buffer.WriteString("\r\n ")
+		line = line[cut:]
+	}
+	buffer.WriteString(line)
+	buffer.WriteString("\r\n")
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+func escape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ",", `\,`, ";", `\;`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+func unescape(s string) string {
+	return strings.NewReplacer(`\\`, `\`, `\,`, ",", `\;`, ";", `\n`, "\n", `\N`, "\n").Replace(s)
+}
+
+// Decoder read cards one at a time from a multi card stream
+type Decoder struct {
+	scanner *bufio.Scanner
+	pending *string
+}
+
+// NewDecoder create decoder of version 3.0 and 4.0 cards
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{
+		scanner: bufio.NewScanner(r),
+	}
+}
+
+// Decode read next card, io.EOF is returned when there is no card left
+func (d *Decoder) Decode() (*Card, error) {
+	var card *Card
+
+	for {
+		line, err := d.readLine()
+		if io.EOF == err {
+			if nil != card {
+				return nil, ErrMalformed
+			}
+			return nil, io.EOF
+		}
+		if nil != err {
+			return nil, err
+		}
+
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		name, params, value := parseLine(line)
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VCARD"):
+			if nil != card {
+				return nil, ErrMalformed
+			}
+			card = &Card{}
+			continue
+		case nil == card:
+			return nil, ErrMalformed
+		case name == "END" && strings.EqualFold(value, "VCARD"):
+			return card, nil
+		}
+
+		switch name {
+		case "UID":
+			card.UID = strings.TrimPrefix(value, "urn:uuid:")
+		case "FN":
+			card.FormattedName = unescape(value)
+		case "N":
+			if card.FormattedName == "" {
+				card.FormattedName = formatName(value)
+			}
+		case "TEL":
+			card.Tels = append(card.Tels, typedValue(params, strings.TrimPrefix(value, "tel:")))
+		case "EMAIL":
+			card.Emails = append(card.Emails, typedValue(params, unescape(value)))
+		case "ADR":
+			card.Addresses = append(card.Addresses, typedValue(params, formatAddress(value)))
+		case "REV":
+			card.Revision = value
+		}
+	}
+}
+
+// readLine return next unfolded content line
+func (d *Decoder) readLine() (string, error) {
+	var line string
+	if nil != d.pending {
+		line = *d.pending
+		d.pending = nil
+	} else {
+		if !d.scanner.Scan() {
+			if err := d.scanner.Err(); nil != err {
+				return "", err
+			}
+			return "", io.EOF
+		}
+		line = d.scanner.Text()
+	}
+
+	for d.scanner.Scan() {
+		next := d.scanner.Text()
+		if strings.HasPrefix(next, " ") || strings.HasPrefix(next, "\t") {
+			line += next[1:]
+			continue
+		}
+		d.pending = &next
+		break
+	}
+
+	return strings.TrimRight(line, "\r"), d.scanner.Err()
+}
+
+// parseLine split content line into upper cased name without group, params and raw value
+func parseLine(line string) (string, map[string][]string, string) {
+	colon := strings.Index(line, ":")
+	if colon < 0 {
+		return strings.ToUpper(line), nil, ""
+	}
+
+	parts := strings.Split(line[:colon], ";")
+	name := strings.ToUpper(parts[0])
+	if dot := strings.LastIndex(name, "."); dot >= 0 {
+		name = name[dot+1:]
+	}
+
+	params := make(map[string][]string)
+	for _, part := range parts[1:] {
+		kv := strings.SplitN(part, "=", 2)
+		key := strings.ToUpper(kv[0])
+		if len(kv) == 1 {
+			// 2.1 and 3.0 allow bare types, e.g. TEL;CELL:
+			params["TYPE"] = append(params["TYPE"], kv[0])
+			continue
+		}
+		for _, v := range strings.Split(strings.Trim(kv[1], `"`), ",") {
+			params[key] = append(params[key], v)
+		}
+	}
+
+	return name, params, line[colon+1:]
+}
+
+func typedValue(params map[string][]string, value string) Value {
+	v := Value{Value: value}
+	for _, t := range params["TYPE"] {
+		t = strings.ToLower(t)
+		if t == "pref" {
+			v.Preferred = true
+			continue
+		}
+		v.Types = append(v.Types, t)
+	}
+	if len(params["PREF"]) > 0 {
+		v.Preferred = true
+	}
+	return v
+}
+
+// splitComponents split structured value on unescaped semicolon
+func splitComponents(value string) []string {
+	components := make([]string, 0)
+	var current strings.Builder
+	escaped := false
+	for _, r := range value {
+		switch {
+		case escaped:
+			current.WriteRune('\\')
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == ';':
+			components = append(components, unescape(current.String()))
+			current.Reset()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	return append(components, unescape(current.String()))
+}
+
+// formatName build display name from N, family;given;additional;prefix;suffix
+func formatName(value string) string {
+	c := splitComponents(value)
+	order := []int{3, 1, 2, 0, 4}
+	parts := make([]string, 0, len(order))
+	for _, i := range order {
+		if i < len(c) && strings.TrimSpace(c[i]) != "" {
+			parts = append(parts, strings.TrimSpace(c[i]))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// formatAddress join non empty ADR components into one line
+func formatAddress(value string) string {
+	parts := make([]string, 0)
+	for _, c := range splitComponents(value) {
+		if strings.TrimSpace(c) != "" {
+			parts = append(parts, strings.TrimSpace(c))
+		}
+	}
+	return strings.Join(parts, ", ")
+}